@@ -0,0 +1,212 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Bundle returns a compound document holding the root schema and every
+// external schema resource it references, directly or not. The documents
+// referenced by "$ref" and "$dynamicRef" that are not part of the root are
+// fetched with the loader, DefaultRegistry when nil, and embedded under the
+// "$defs" of the bundle with their own "$id", made absolute, or the URI they
+// were fetched from when they have none. References are kept untouched, as
+// they keep designating the same schemas, and the root is not modified.
+// Bundling a bundle returns an identical document.
+//
+// draft-bhutton-json-schema-01 section 9.3
+func Bundle(root *Schema, loader Loader) (*Schema, error) {
+	if loader == nil {
+		loader = DefaultRegistry
+	}
+	if root == nil {
+		return nil, errors.New("jsonschema: cannot bundle a nil schema")
+	}
+	bundle := root.Clone()
+	base, err := resolveURI("", bundle.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", bundle.ID, err)
+	}
+	local := make(map[string]*Schema)
+	if err := indexSchema(local, bundle, stripFragment(base)); err != nil {
+		return nil, err
+	}
+
+	type resource struct {
+		schema *Schema
+		base   string
+	}
+	queue := []resource{{bundle, stripFragment(base)}}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		var docs []string
+		if err := collectRefs(r.schema, r.base, &docs); err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if _, ok := local[doc+"#"]; ok {
+				continue
+			}
+			s, err := loader.Load(doc)
+			if err != nil {
+				return nil, fmt.Errorf("jsonschema: cannot bundle %q: %w", doc, err)
+			}
+			if s == nil {
+				return nil, fmt.Errorf("jsonschema: cannot bundle %q: nil schema", doc)
+			}
+			s = s.Clone()
+			if s.boolean != nil {
+				return nil, fmt.Errorf("jsonschema: cannot bundle %q: boolean schemas cannot have an $id", doc)
+			}
+			// the document keeps its own $id, made absolute since it is no
+			// longer resolved against the URI it was retrieved from
+			id, err := resolveURI(doc, s.ID.String())
+			if err != nil {
+				return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+			}
+			s.ID = ID(stripFragment(id))
+			if bundle.Definitions == nil {
+				bundle.Definitions = make(Definitions)
+			}
+			bundle.Definitions[defName(bundle.Definitions, doc)] = s
+			if err := indexSchema(local, s, doc); err != nil {
+				return nil, err
+			}
+			queue = append(queue, resource{s, doc})
+		}
+	}
+	return bundle, nil
+}
+
+// Unbundle splits a compound document into its schema resources, by their
+// absolute "$id". Every schema resource embedded under a "$defs" keyword is
+// removed from its parent, so the root document is returned under its own
+// "$id", or EmptyID. The root is not modified.
+//
+// The returned map can be used as a MapLoader to compile the root document.
+// References by JSON Pointer crossing the boundary of an extracted resource,
+// rather than by its "$id", are not rewritten.
+func Unbundle(root *Schema) (map[ID]*Schema, error) {
+	if root == nil {
+		return nil, errors.New("jsonschema: cannot unbundle a nil schema")
+	}
+	doc := root.Clone()
+	base, err := resolveURI("", doc.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", doc.ID, err)
+	}
+	resources := map[ID]*Schema{ID(stripFragment(base)): doc}
+	if err := extractResources(doc, stripFragment(base), resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// extractResources moves the schema resources embedded under the "$defs" of
+// the schema and of its sub-schemas to the resources map.
+func extractResources(s *Schema, base string, resources map[ID]*Schema) error {
+	if s == nil || s.boolean != nil {
+		return nil
+	}
+	if s.ID != EmptyID {
+		id, err := resolveURI(base, s.ID.String())
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+		}
+		base = stripFragment(id)
+	}
+	extracted := false
+	for _, name := range sortedKeys(s.Definitions) {
+		def := s.Definitions[name]
+		if def == nil || def.boolean != nil || def.ID == EmptyID {
+			continue
+		}
+		id, err := resolveURI(base, def.ID.String())
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid $id %q: %w", def.ID, err)
+		}
+		id = stripFragment(id)
+		if _, ok := resources[ID(id)]; ok {
+			return fmt.Errorf("jsonschema: duplicate schema resource %q", id)
+		}
+		delete(s.Definitions, name)
+		extracted = true
+		def.ID = ID(id)
+		resources[ID(id)] = def
+		if err := extractResources(def, id, resources); err != nil {
+			return err
+		}
+	}
+	if extracted && len(s.Definitions) == 0 {
+		s.Definitions = nil
+	}
+	for _, sub := range s.subschemas() {
+		if err := extractResources(sub, base, resources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectRefs appends the absolute URIs, without fragment, of the documents
+// referenced by the schema and its sub-schemas.
+func collectRefs(s *Schema, base string, docs *[]string) error {
+	if s == nil || s.boolean != nil {
+		return nil
+	}
+	if s.ID != EmptyID {
+		id, err := resolveURI(base, s.ID.String())
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+		}
+		base = stripFragment(id)
+	}
+	for _, ref := range []string{s.Ref, s.DynamicRef} {
+		if ref == "" {
+			continue
+		}
+		uri, err := resolveURI(base, ref)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid $ref %q: %w", ref, err)
+		}
+		*docs = append(*docs, stripFragment(uri))
+	}
+	for _, sub := range s.subschemas() {
+		if err := collectRefs(sub, base, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defName returns an unused "$defs" name for the document, derived from the
+// last segment of its URI: "https://example.com/schemas/user.json" is
+// embedded as "user".
+func defName(defs Definitions, uri string) string {
+	name := strings.TrimSuffix(path.Base(uri), ".json")
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "schema"
+	}
+	return uniqueDefName(defs, name)
+}
+
+// uniqueDefName returns the name, or the name with a numeric suffix when it
+// is already used.
+func uniqueDefName(defs Definitions, name string) string {
+	if _, ok := defs[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		n := name + "-" + strconv.Itoa(i)
+		if _, ok := defs[n]; !ok {
+			return n
+		}
+	}
+}
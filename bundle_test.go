@@ -0,0 +1,125 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	loader := MapLoader{
+		"https://example.com/schemas/address": mustUnmarshalSchema(t, `{
+			"type": "object",
+			"properties": {"country": {"$ref": "country.json#/$defs/code"}}
+		}`),
+		"https://example.com/schemas/country.json": mustUnmarshalSchema(t, `{
+			"$id": "https://example.com/schemas/country.json",
+			"$defs": {"code": {"type": "string", "pattern": "^[A-Z]{2}$"}}
+		}`),
+		"https://example.com/other/address": mustUnmarshalSchema(t, `{"$id": "address", "type": "string"}`),
+		"https://example.com/latest/tag":    mustUnmarshalSchema(t, `{"$id": "/v2/tag", "type": "string"}`),
+	}
+	root := mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/schemas/person",
+		"properties": {
+			"home": {"$ref": "address"},
+			"work": {"$ref": "https://example.com/schemas/address"},
+			"note": {"$ref": "/other/address"},
+			"self": {"$ref": "#"}
+		}
+	}`)
+
+	bundle, err := Bundle(root, loader)
+	require.NoError(t, err)
+	data, err := bundle.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$id": "https://example.com/schemas/person",
+		"properties": {
+			"home": {"$ref": "address"},
+			"work": {"$ref": "https://example.com/schemas/address"},
+			"note": {"$ref": "/other/address"},
+			"self": {"$ref": "#"}
+		},
+		"$defs": {
+			"address": {
+				"$id": "https://example.com/schemas/address",
+				"type": "object",
+				"properties": {"country": {"$ref": "country.json#/$defs/code"}}
+			},
+			"address-2": {"$id": "https://example.com/other/address", "type": "string"},
+			"country": {
+				"$id": "https://example.com/schemas/country.json",
+				"$defs": {"code": {"type": "string", "pattern": "^[A-Z]{2}$"}}
+			}
+		}
+	}`, string(data))
+	assert.Nil(t, root.Definitions, "the root must not be modified")
+
+	again, err := Bundle(bundle, MapLoader{})
+	require.NoError(t, err)
+	againData, err := again.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(againData))
+
+	v, err := Compile(bundle, WithRegistry(NewRegistry()))
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `{"home":{"country":"FR"},"note":"x"}`)))
+	assert.ErrorContains(t, v.Validate(mustDecodeInstance(t, `{"work":{"country":"France"}}`)), "/work/country")
+
+	// the documents keep their own $id
+	bundle, err = Bundle(mustUnmarshalSchema(t, `{"$ref":"https://example.com/latest/tag"}`), loader)
+	require.NoError(t, err)
+	assert.Equal(t, ID("https://example.com/v2/tag"), bundle.Definitions["tag"].ID)
+
+	_, err = Bundle(mustUnmarshalSchema(t, `{"$ref":"https://example.com/missing"}`), loader)
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+	assert.ErrorContains(t, err, `cannot bundle "https://example.com/missing"`)
+}
+
+func TestUnbundle(t *testing.T) {
+	bundle := mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/schemas/person",
+		"properties": {"home": {"$ref": "address"}, "name": {"$ref": "#/$defs/name"}},
+		"$defs": {
+			"name": {"type": "string"},
+			"address": {
+				"$id": "address",
+				"properties": {"country": {"$ref": "country"}},
+				"$defs": {"country": {"$id": "country", "type": "string"}}
+			}
+		}
+	}`)
+
+	resources, err := Unbundle(bundle)
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	want := map[ID]string{
+		"https://example.com/schemas/person": `{
+			"$id": "https://example.com/schemas/person",
+			"properties": {"home": {"$ref": "address"}, "name": {"$ref": "#/$defs/name"}},
+			"$defs": {"name": {"type": "string"}}
+		}`,
+		"https://example.com/schemas/address": `{
+			"$id": "https://example.com/schemas/address",
+			"properties": {"country": {"$ref": "country"}}
+		}`,
+		"https://example.com/schemas/country": `{"$id": "https://example.com/schemas/country", "type": "string"}`,
+	}
+	for id, s := range want {
+		require.Contains(t, resources, id)
+		data, err := resources[id].MarshalJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, s, string(data), id)
+	}
+	assert.Len(t, bundle.Definitions, 2, "the bundle must not be modified")
+
+	rebundled, err := Bundle(resources["https://example.com/schemas/person"], MapLoader(resources))
+	require.NoError(t, err)
+	assert.Len(t, rebundled.Definitions, 3)
+
+	_, err = Unbundle(mustUnmarshalSchema(t, `{"$defs": {"a": {"$id": "https://example.com/a"}, "b": {"$id": "https://example.com/a"}}}`))
+	assert.ErrorContains(t, err, `duplicate schema resource "https://example.com/a"`)
+}
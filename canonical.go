@@ -0,0 +1,190 @@
+package jsonschema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json/jsontext"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Canonicalize rewrites a JSON document in its canonical form, following the
+// JSON Canonicalization Scheme: no whitespace, object members sorted by the
+// UTF-16 code units of their names, numbers formatted as in ECMAScript, and
+// strings with the minimal escaping. Equivalent documents, such as ones with
+// "1.0" and "1" or with members in another order, have the same canonical
+// form.
+//
+// RFC 8785
+func Canonicalize(data []byte) ([]byte, error) {
+	dec := jsontext.NewDecoder(bytes.NewReader(data))
+	var buf bytes.Buffer
+	if err := canonicalValue(dec, &buf); err != nil {
+		return nil, fmt.Errorf("jsonschema: cannot canonicalize: %w", err)
+	}
+	if _, err := dec.ReadToken(); err != io.EOF {
+		return nil, fmt.Errorf("jsonschema: cannot canonicalize: unexpected data after the document")
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalCanonical returns the canonical JSON of the schema, see
+// Canonicalize. Unlike MarshalJSON, its output does not depend on the order
+// of the properties nor on the text of numbers.
+func (t *Schema) MarshalCanonical() ([]byte, error) {
+	data, err := t.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(data)
+}
+
+// Fingerprint returns the hex-encoded SHA-256 digest of the canonical JSON of
+// the schema, which is the same for logically identical schemas and can be
+// used as a content hash.
+func (t *Schema) Fingerprint() (string, error) {
+	data, err := t.MarshalCanonical()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func canonicalValue(dec *jsontext.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	switch tok.Kind() {
+	case 'n', 't', 'f':
+		buf.WriteString(tok.String())
+	case '"':
+		writeCanonicalString(buf, tok.String())
+	case '0':
+		f, err := strconv.ParseFloat(tok.String(), 64)
+		if err != nil {
+			return fmt.Errorf("number %s out of range", tok.String())
+		}
+		buf.WriteString(formatCanonicalNumber(f))
+	case '[':
+		buf.WriteByte('[')
+		for i := 0; dec.PeekKind() != ']'; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := canonicalValue(dec, buf); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.ReadToken(); err != nil {
+			return err
+		}
+		buf.WriteByte(']')
+	case '{':
+		type member struct {
+			name  string
+			key   []uint16
+			value []byte
+		}
+		var members []member
+		for dec.PeekKind() != '}' {
+			tok, err := dec.ReadToken()
+			if err != nil {
+				return err
+			}
+			name := tok.String()
+			var value bytes.Buffer
+			if err := canonicalValue(dec, &value); err != nil {
+				return err
+			}
+			members = append(members, member{name, utf16.Encode([]rune(name)), value.Bytes()})
+		}
+		if _, err := dec.ReadToken(); err != nil {
+			return err
+		}
+		slices.SortFunc(members, func(a, b member) int { return slices.Compare(a.key, b.key) })
+		buf.WriteByte('{')
+		for i, m := range members {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, m.name)
+			buf.WriteByte(':')
+			buf.Write(m.value)
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected %s", tok.Kind())
+	}
+	return nil
+}
+
+// writeCanonicalString writes a JSON string escaping only the quotation
+// mark, the reverse solidus and the control characters.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatCanonicalNumber formats a finite number as ECMAScript does, with the
+// shortest digits that round-trip.
+func formatCanonicalNumber(f float64) string {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "0" // -0, and JSON has no NaN nor infinity
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	k, n := len(digits), e+1 // n is the position of the decimal point
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+	exponent := "e+" + strconv.Itoa(n-1)
+	if n-1 < 0 {
+		exponent = "e-" + strconv.Itoa(1-n)
+	}
+	if k == 1 {
+		return sign + digits + exponent
+	}
+	return sign + digits[:1] + "." + digits[1:] + exponent
+}
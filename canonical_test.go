@@ -0,0 +1,75 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		// RFC 8785 section 3.2.2
+		{
+			`{
+				"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+				"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+				"literals": [null, true, false]
+			}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		// RFC 8785 section 3.2.3
+		{
+			`{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}",
+		},
+		{`[1e21, 1e20, 1e-7, 0.000001, -0, 5e-324, 1.7976931348623157e308, -12.5e3, 100]`,
+			`[1e+21,100000000000000000000,1e-7,0.000001,0,5e-324,1.7976931348623157e+308,-12500,100]`},
+		{` { "b" : [ { } , [ ] ] , "a" : "<&>" } `, `{"a":"<&>","b":[{},[]]}`},
+	} {
+		out, err := Canonicalize([]byte(tc.in))
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.out, string(out))
+	}
+
+	for _, in := range []string{`{"a": 1e400}`, `[1, 2`, `1 2`, `1 x`, `{} ]`} {
+		_, err := Canonicalize([]byte(in))
+		assert.Error(t, err, in)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := mustUnmarshalSchema(t, `{"properties": {"a": {"minimum": 1}, "b": {"type": "string"}}, "x-order": 2.0}`)
+	b := mustUnmarshalSchema(t, `{"x-order": 2, "properties": {"b": {"type": "string"}, "a": {"minimum": 1.0}}}`)
+	c := mustUnmarshalSchema(t, `{"properties": {"a": {"minimum": 2}, "b": {"type": "string"}}, "x-order": 2}`)
+
+	fa, err := a.Fingerprint()
+	require.NoError(t, err)
+	fb, err := b.Fingerprint()
+	require.NoError(t, err)
+	fc, err := c.Fingerprint()
+	require.NoError(t, err)
+	assert.Len(t, fa, 64)
+	assert.Equal(t, fa, fb)
+	assert.NotEqual(t, fa, fc)
+}
+
+func TestReflectorFingerprintID(t *testing.T) {
+	type Item struct {
+		Name string `json:"name"`
+	}
+
+	s := (&Reflector{FingerprintID: true}).Reflect(&Item{})
+	require.True(t, strings.HasPrefix(s.ID.String(), "urn:sha256:"), s.ID)
+	withoutID := s.Clone()
+	withoutID.ID = EmptyID
+	fingerprint, err := withoutID.Fingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, ID("urn:sha256:"+fingerprint), s.ID)
+
+	s = (&Reflector{FingerprintID: true, BaseSchemaID: "https://example.com/schemas"}).Reflect(&Item{})
+	assert.Equal(t, ID("https://example.com/schemas/"+fingerprint), s.ID)
+}
@@ -0,0 +1,264 @@
+package jsonschema
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// Clone returns a deep copy of the schema, sharing nothing with it: every
+// sub-schema, the order of the properties, the values of "enum", "const",
+// "default", "examples" and Extras, and the boolean value of TrueSchema and
+// FalseSchema are copied. The schema must not contain cycles. Clone returns
+// nil for a nil schema.
+func (t *Schema) Clone() *Schema {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	if t.boolean != nil {
+		b := *t.boolean
+		c.boolean = &b
+	}
+	c.Vocabulary = maps.Clone(t.Vocabulary)
+	c.Definitions = cloneSchemaMap(t.Definitions)
+	c.AllOf = cloneSchemaList(t.AllOf)
+	c.AnyOf = cloneSchemaList(t.AnyOf)
+	c.OneOf = cloneSchemaList(t.OneOf)
+	c.PrefixItems = cloneSchemaList(t.PrefixItems)
+	for _, kw := range singleKeywords {
+		*kw.field(&c) = (*kw.field(t)).Clone()
+	}
+	c.DependentSchemas = cloneSchemaMap(t.DependentSchemas)
+	c.PatternProperties = cloneSchemaMap(t.PatternProperties)
+	if t.Properties != nil {
+		c.Properties = NewPropertiesCap(t.Properties.Len())
+		for name, sub := range t.Properties.All() {
+			c.Properties.Set(name, sub.Clone())
+		}
+	}
+
+	c.TypeEnhanced = slices.Clone(t.TypeEnhanced)
+	c.Enum = cloneValue(t.Enum).([]any)
+	c.Const = cloneValue(t.Const)
+	c.MaxLength = cloneUint(t.MaxLength)
+	c.MinLength = cloneUint(t.MinLength)
+	c.MaxItems = cloneUint(t.MaxItems)
+	c.MinItems = cloneUint(t.MinItems)
+	c.MaxContains = cloneUint(t.MaxContains)
+	c.MinContains = cloneUint(t.MinContains)
+	c.MaxProperties = cloneUint(t.MaxProperties)
+	c.MinProperties = cloneUint(t.MinProperties)
+	c.Required = slices.Clone(t.Required)
+	if t.DependentRequired != nil {
+		c.DependentRequired = make(map[string][]string, len(t.DependentRequired))
+		for name, required := range t.DependentRequired {
+			c.DependentRequired[name] = slices.Clone(required)
+		}
+	}
+	c.Default = cloneValue(t.Default)
+	c.Examples = cloneValue(t.Examples).([]any)
+	c.Extras = cloneValue(t.Extras).(map[string]any)
+	return &c
+}
+
+func cloneSchemaList(list []*Schema) []*Schema {
+	if list == nil {
+		return nil
+	}
+	c := make([]*Schema, len(list))
+	for i, s := range list {
+		c[i] = s.Clone()
+	}
+	return c
+}
+
+func cloneSchemaMap[M ~map[string]*Schema](m M) M {
+	if m == nil {
+		return nil
+	}
+	c := make(M, len(m))
+	for name, s := range m {
+		c[name] = s.Clone()
+	}
+	return c
+}
+
+func cloneUint(p *uint64) *uint64 {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// cloneValue returns a deep copy of a JSON value, as held by "enum" or
+// Extras. Schemas set in Extras by ConvertTo are cloned too, and values of
+// other types are shared.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case []any:
+		if v == nil {
+			return v
+		}
+		c := make([]any, len(v))
+		for i, item := range v {
+			c[i] = cloneValue(item)
+		}
+		return c
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		c := make(map[string]any, len(v))
+		for k, item := range v {
+			c[k] = cloneValue(item)
+		}
+		return c
+	case *Schema:
+		return v.Clone()
+	case []*Schema:
+		return cloneSchemaList(v)
+	case map[string]*Schema:
+		return cloneSchemaMap(v)
+	case []string:
+		return slices.Clone(v)
+	}
+	return v
+}
+
+// Equal reports whether two schemas are equivalent. Unlike comparing their
+// JSON, numbers are compared by value, so that "1.0" equals "1", and the
+// order of "required", "dependentRequired" and the list of types is ignored,
+// as is the order of the properties. An empty schema equals TrueSchema.
+func Equal(a, b *Schema) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if a.boolean != nil || b.boolean != nil {
+		return a.isTrue() && b.isTrue() || a.isFalse() && b.isFalse()
+	}
+	if !equalSet(a.types(), b.types()) {
+		return false
+	}
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	st := va.Type()
+	for i := range st.NumField() {
+		switch st.Field(i).Name {
+		case "Type", "TypeEnhanced", "boolean":
+			continue
+		case "Const":
+			if (a.Const == nil) != (b.Const == nil) || !jsonEqual(a.Const, b.Const) {
+				return false
+			}
+		case "Required":
+			if !equalSet(a.Required, b.Required) {
+				return false
+			}
+		case "DependentRequired":
+			if len(a.DependentRequired) != len(b.DependentRequired) {
+				return false
+			}
+			for name, required := range a.DependentRequired {
+				other, ok := b.DependentRequired[name]
+				if !ok || !equalSet(required, other) {
+					return false
+				}
+			}
+		default:
+			if !equalField(va.Field(i), vb.Field(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// equalField compares a field of two schemas, see Equal.
+func equalField(x, y reflect.Value) bool {
+	switch x.Kind() {
+	case reflect.Map, reflect.Slice:
+		if x.Len() == 0 && y.Len() == 0 {
+			return true
+		}
+	}
+	switch x := x.Interface().(type) {
+	case *Schema:
+		return Equal(x, y.Interface().(*Schema))
+	case []*Schema:
+		return slices.EqualFunc(x, y.Interface().([]*Schema), Equal)
+	case Definitions:
+		return equalSchemaMap(x, y.Interface().(Definitions))
+	case map[string]*Schema:
+		return equalSchemaMap(x, y.Interface().(map[string]*Schema))
+	case *Properties:
+		y := y.Interface().(*Properties)
+		if x.Len() != y.Len() {
+			return false
+		}
+		for name, s := range x.All() {
+			other, ok := y.Get(name)
+			if !ok || !Equal(s, other) {
+				return false
+			}
+		}
+		return true
+	case jsonv1.Number:
+		y := y.Interface().(jsonv1.Number)
+		if x == "" || y == "" {
+			return x == y
+		}
+		return jsonEqual(x, y)
+	case []any, map[string]any:
+		return jsonEqual(x, y.Interface())
+	}
+	if x.Kind() == reflect.Interface {
+		return jsonEqual(x.Interface(), y.Interface())
+	}
+	return reflect.DeepEqual(x.Interface(), y.Interface())
+}
+
+func equalSchemaMap[M ~map[string]*Schema](x, y M) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for name, s := range x {
+		other, ok := y[name]
+		if !ok || !Equal(s, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalSet reports whether two lists hold the same strings, in any order.
+func equalSet(x, y []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(x)), slices.Sorted(slices.Values(y)))
+}
+
+// types returns the types of the schema, from Type or TypeEnhanced.
+func (t *Schema) types() []string {
+	if t.Type != "" {
+		return []string{t.Type}
+	}
+	return t.TypeEnhanced
+}
+
+// isTrue reports whether the schema is TrueSchema or an empty schema, and
+// isFalse whether it is FalseSchema.
+func (t *Schema) isTrue() bool {
+	if t.boolean != nil {
+		return *t.boolean
+	}
+	empty, err := isEmptySchema(t)
+	return err == nil && empty
+}
+
+func (t *Schema) isFalse() bool {
+	return t.boolean != nil && !*t.boolean
+}
@@ -0,0 +1,84 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$defs": {"id": {"type": "integer", "minimum": 1}},
+		"properties": {
+			"b": {"enum": [{"a": [1, 2]}, "x"], "maxLength": 3},
+			"a": {"items": true, "default": {"k": "v"}}
+		},
+		"required": ["a", "b"],
+		"dependentRequired": {"a": ["b"]},
+		"additionalProperties": false,
+		"x-meta": {"tags": ["t"]}
+	}`)
+	s.AllOf = []*Schema{TrueSchema}
+
+	c := s.Clone()
+	require.True(t, Equal(s, c))
+	before, err := s.MarshalJSON()
+	require.NoError(t, err)
+	data, err := c.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(data), "properties keep their order")
+
+	b, _ := c.Properties.Get("b")
+	b.Enum[0].(map[string]any)["a"].([]any)[0] = 5
+	*b.MaxLength = 4
+	a, _ := c.Properties.Get("a")
+	a.Default.(map[string]any)["k"] = "w"
+	*a.Items.boolean = false
+	c.Definitions["id"].Minimum = "2"
+	c.Required[0] = "c"
+	c.DependentRequired["a"][0] = "c"
+	*c.AdditionalProperties.boolean = true
+	c.Extras["x-meta"].(map[string]any)["tags"].([]any)[0] = "u"
+	*c.AllOf[0].boolean = false
+
+	after, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+	assert.True(t, *TrueSchema.boolean)
+	assert.False(t, Equal(s, c))
+
+	assert.Nil(t, (*Schema)(nil).Clone())
+}
+
+func TestEqual(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{`{"minimum": 1}`, `{"minimum": 1.0}`, true},
+		{`{"minimum": 1}`, `{"minimum": 1.5}`, false},
+		{`{"minimum": 1}`, `{"maximum": 1}`, false},
+		{`{"required": ["a", "b"]}`, `{"required": ["b", "a"]}`, true},
+		{`{"required": ["a", "b"]}`, `{"required": ["a"]}`, false},
+		{`{"type": ["string", "null"]}`, `{"type": ["null", "string"]}`, true},
+		{`{"type": ["string"]}`, `{"type": "string"}`, true},
+		{`{"properties": {"a": {}, "b": false}}`, `{"properties": {"b": false, "a": true}}`, true},
+		{`{"properties": {"a": {}}}`, `{"properties": {"a": false}}`, false},
+		{`{"const": {"n": 10}}`, `{"const": {"n": 1e1}}`, true},
+		{`{"enum": [1, "a"]}`, `{"enum": ["a", 1]}`, false},
+		{`{"x-order": 2}`, `{"x-order": 2.0}`, true},
+		{`{"x-order": 2}`, `{}`, false},
+		{`{"dependentRequired": {"a": ["b", "c"]}}`, `{"dependentRequired": {"a": ["c", "b"]}}`, true},
+		{`{"$defs": {"a": {"type": "string"}}}`, `{"$defs": {"a": {"type": "integer"}}}`, false},
+		{`{}`, `true`, true},
+		{`false`, `true`, false},
+		{`{"not": {}}`, `false`, false},
+	} {
+		a, b := mustUnmarshalSchema(t, tc.a), mustUnmarshalSchema(t, tc.b)
+		assert.Equal(t, tc.equal, Equal(a, b), "%s and %s", tc.a, tc.b)
+		assert.Equal(t, tc.equal, Equal(b, a), "%s and %s", tc.b, tc.a)
+	}
+	assert.True(t, Equal(nil, nil))
+	assert.False(t, Equal(nil, TrueSchema))
+}
@@ -0,0 +1,659 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// Validator is a compiled Schema ready to validate instances. All references
+// are resolved, regular expressions compiled and numeric keywords parsed once
+// by Compile, so a Validator can be reused for any number of instances and
+// safely shared between goroutines.
+type Validator struct {
+	root   *schemaNode
+	schema *Schema
+
+	// track is set when the schema uses "unevaluatedItems" or
+	// "unevaluatedProperties", which need the evaluated locations of every
+	// in-place applicator.
+	track bool
+
+	// dynamic indexes the nodes declaring a "$dynamicAnchor" by the URI of
+	// the anchor. It is nil when no "$dynamicRef" depends on the dynamic scope.
+	dynamic map[string]*schemaNode
+}
+
+type compileOptions struct {
+	formats       *FormatRegistry
+	assertFormat  bool
+	assertContent bool
+	registry      *Registry
+}
+
+// CompileOption allows for special configuration options when compiling a
+// Validator.
+type CompileOption func(*compileOptions)
+
+// WithFormatAssertion makes the Validator fail instances that do not conform
+// to the "format" of their schema. By default "format" is only collected as
+// an annotation, as required by the specification.
+func WithFormatAssertion() CompileOption {
+	return func(o *compileOptions) {
+		o.assertFormat = true
+	}
+}
+
+// WithFormats sets the registry used to look up format checkers instead of
+// DefaultFormats. It implies WithFormatAssertion.
+func WithFormats(r *FormatRegistry) CompileOption {
+	return func(o *compileOptions) {
+		o.formats = r
+		o.assertFormat = true
+	}
+}
+
+// WithRegistry sets the registry used to find the documents of references
+// to other schemas instead of DefaultRegistry.
+func WithRegistry(r *Registry) CompileOption {
+	return func(o *compileOptions) {
+		o.registry = r
+	}
+}
+
+// WithLoader resolves references to other schemas with a new Registry using
+// the loaders, see WithRegistry.
+func WithLoader(loaders ...Loader) CompileOption {
+	return WithRegistry(NewRegistry(loaders...))
+}
+
+// WithContentAssertion makes the Validator decode string instances with their
+// "contentEncoding" and parse the result according to their
+// "contentMediaType", failing instances that cannot be decoded or parsed. The
+// parsed document is then validated against "contentSchema". JSON media types
+// are the only ones parsed, others are only decoded. By default the content
+// keywords are only collected as annotations, as required by the
+// specification.
+//
+// RFC draft-bhutton-json-schema-validation-00 section 8
+func WithContentAssertion() CompileOption {
+	return func(o *compileOptions) {
+		o.assertContent = true
+	}
+}
+
+// Compile prepares the schema for validation. References to other documents
+// are resolved with DefaultRegistry, or the registry given by WithRegistry or
+// WithLoader. It returns an error when the schema contains a reference that
+// cannot be resolved or a pattern that is not
+// a valid regular expression. When formats are asserted, a format without a
+// registered checker is also an error, and so is an unknown "contentEncoding"
+// when content is asserted.
+//
+// The schema must not be modified while the returned Validator is in use.
+func Compile(s *Schema, opts ...CompileOption) (*Validator, error) {
+	if s == nil {
+		return nil, fmt.Errorf("jsonschema: cannot compile a nil schema")
+	}
+	c := &compiler{
+		index:   make(map[string]*schemaNode),
+		dynamic: make(map[string]*schemaNode),
+		loaded:  make(map[string]bool),
+		opts:    compileOptions{formats: DefaultFormats, registry: DefaultRegistry},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	root, err := c.compileDocument(s, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.link(); err != nil {
+		return nil, err
+	}
+	v := &Validator{root: root, schema: s, track: c.track}
+	if c.dynamicScope {
+		v.dynamic = c.dynamic
+	}
+	return v, nil
+}
+
+// Schema returns the schema the Validator was compiled from.
+func (v *Validator) Schema() *Schema {
+	return v.schema
+}
+
+// schemaNode is the compiled form of a Schema.
+type schemaNode struct {
+	schema *Schema
+
+	// base is the absolute URI of the schema resource the node belongs to and
+	// ptr the JSON Pointer of the node inside that resource.
+	base string
+	ptr  string
+
+	boolean *bool
+
+	ref        *schemaNode
+	dynamicRef *schemaNode
+	// dynamicName is the anchor name of a "$dynamicRef" that must be resolved
+	// through the dynamic scope, see evalState.resolveDynamic.
+	dynamicName string
+
+	allOf            []*schemaNode
+	anyOf            []*schemaNode
+	oneOf            []*schemaNode
+	not              *schemaNode
+	ifNode           *schemaNode
+	thenNode         *schemaNode
+	elseNode         *schemaNode
+	dependentSchemas []namedNode
+
+	prefixItems []*schemaNode
+	items       *schemaNode
+	contains    *schemaNode
+
+	properties           map[string]*schemaNode
+	patternProperties    []patternNode
+	additionalProperties *schemaNode
+	propertyNames        *schemaNode
+
+	unevaluatedItems      *schemaNode
+	unevaluatedProperties *schemaNode
+
+	types             []string
+	enum              []any
+	constant          any
+	hasConst          bool
+	multipleOf        *numberBound
+	maximum           *numberBound
+	exclusiveMaximum  *numberBound
+	minimum           *numberBound
+	exclusiveMinimum  *numberBound
+	maxLength         int64
+	minLength         int64
+	pattern           *regexp.Regexp
+	format            FormatChecker
+	decodeContent     contentDecoder
+	parseContent      contentParser
+	contentSchema     *schemaNode
+	maxItems          int64
+	minItems          int64
+	uniqueItems       bool
+	maxContains       int64
+	minContains       int64
+	maxProperties     int64
+	minProperties     int64
+	required          []string
+	dependentRequired []namedStrings
+	keywords          []namedKeyword
+}
+
+type namedNode struct {
+	name string
+	node *schemaNode
+}
+
+type namedStrings struct {
+	name   string
+	values []string
+}
+
+type namedKeyword struct {
+	name string
+	eval KeywordFunc
+}
+
+type patternNode struct {
+	pattern string
+	re      *regexp.Regexp
+	node    *schemaNode
+}
+
+// numberBound is a numeric keyword value parsed once for fast comparisons.
+// Comparisons are done with float64 arithmetic whenever that is exact and fall
+// back to arbitrary precision otherwise.
+type numberBound struct {
+	text  jsonv1.Number
+	rat   *big.Rat
+	f     float64
+	exact bool
+}
+
+func newNumberBound(n jsonv1.Number) (*numberBound, error) {
+	if n == "" {
+		return nil, nil
+	}
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", n)
+	}
+	f, exact := r.Float64()
+	return &numberBound{text: n, rat: r, f: f, exact: exact}, nil
+}
+
+// cmp compares the instance number with the bound, returning -1, 0 or +1.
+// The number must be valid, see validNumber.
+func (b *numberBound) cmp(v any) int {
+	if f, ok := v.(float64); ok && b.exact {
+		switch {
+		case f < b.f:
+			return -1
+		case f > b.f:
+			return 1
+		}
+		return 0
+	}
+	r, _ := toRat(v)
+	return r.Cmp(b.rat)
+}
+
+// divides reports whether the instance number is a multiple of the bound.
+// The number must be valid, see validNumber.
+func (b *numberBound) divides(v any) bool {
+	if f, ok := v.(float64); ok && b.exact && b.f == math.Trunc(b.f) {
+		return math.Mod(f, b.f) == 0
+	}
+	r, _ := toRat(v)
+	return new(big.Rat).Quo(r, b.rat).IsInt()
+}
+
+// validNumber reports whether the instance number can be compared with the
+// bounds, which is not the case of a malformed jsonv1.Number.
+func validNumber(v any) bool {
+	if _, ok := v.(float64); ok {
+		return true
+	}
+	_, ok := toRat(v)
+	return ok
+}
+
+// compiler turns a Schema tree into schemaNodes, indexing every node by its
+// absolute location so that references can be linked once all are known.
+type compiler struct {
+	index   map[string]*schemaNode
+	dynamic map[string]*schemaNode
+	nodes   []*schemaNode
+	opts    compileOptions
+	track   bool
+
+	// docBase is the URI of the document being compiled and loaded the URIs
+	// of the documents already fetched from the registry.
+	docBase string
+	loaded  map[string]bool
+
+	// dynamicScope is set when a "$dynamicRef" needs the dynamic scope.
+	dynamicScope bool
+}
+
+// compileDocument compiles a whole document retrieved from the URI, which is
+// empty for the schema given to Compile.
+func (c *compiler) compileDocument(s *Schema, uri string) (*schemaNode, error) {
+	base, err := resolveURI(uri, s.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+	}
+	prev := c.docBase
+	c.docBase = stripFragment(base)
+	defer func() { c.docBase = prev }()
+	n, err := c.compile(s, c.docBase, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if uri != "" {
+		c.register(uri+"#", n)
+	}
+	return n, nil
+}
+
+func (c *compiler) compile(s *Schema, base, ptr, docPtr string) (*schemaNode, error) {
+	if s == nil {
+		return nil, nil
+	}
+	if s.ID != EmptyID && ptr != "" {
+		id, err := resolveURI(base, s.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid $id %q at %q: %w", s.ID, docPtr, err)
+		}
+		base, ptr = stripFragment(id), ""
+	}
+
+	n := &schemaNode{schema: s, base: base, ptr: ptr, boolean: s.boolean}
+	c.nodes = append(c.nodes, n)
+	c.register(base+"#"+ptr, n)
+	if docPtr != ptr {
+		c.register(c.docBase+"#"+docPtr, n)
+	}
+	if s.Anchor != "" {
+		c.register(base+"#"+s.Anchor, n)
+	}
+	if s.DynamicAnchor != "" {
+		c.register(base+"#"+s.DynamicAnchor, n)
+		if _, exists := c.dynamic[base+"#"+s.DynamicAnchor]; !exists {
+			c.dynamic[base+"#"+s.DynamicAnchor] = n
+		}
+	}
+	if s.boolean != nil {
+		return n, nil
+	}
+
+	sub := func(ss *Schema, tokens ...string) (*schemaNode, error) {
+		suffix := "/" + strings.Join(tokens, "/")
+		return c.compile(ss, base, ptr+suffix, docPtr+suffix)
+	}
+	subList := func(list []*Schema, keyword string) ([]*schemaNode, error) {
+		if len(list) == 0 {
+			return nil, nil
+		}
+		nodes := make([]*schemaNode, len(list))
+		for i, ss := range list {
+			sn, err := sub(ss, keyword, strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			nodes[i] = sn
+		}
+		return nodes, nil
+	}
+
+	var err error
+	if n.allOf, err = subList(s.AllOf, "allOf"); err != nil {
+		return nil, err
+	}
+	if n.anyOf, err = subList(s.AnyOf, "anyOf"); err != nil {
+		return nil, err
+	}
+	if n.oneOf, err = subList(s.OneOf, "oneOf"); err != nil {
+		return nil, err
+	}
+	if n.prefixItems, err = subList(s.PrefixItems, "prefixItems"); err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		dst     **schemaNode
+		src     *Schema
+		keyword string
+	}{
+		{&n.not, s.Not, "not"},
+		{&n.ifNode, s.If, "if"},
+		{&n.thenNode, s.Then, "then"},
+		{&n.elseNode, s.Else, "else"},
+		{&n.items, s.Items, "items"},
+		{&n.contains, s.Contains, "contains"},
+		{&n.additionalProperties, s.AdditionalProperties, "additionalProperties"},
+		{&n.propertyNames, s.PropertyNames, "propertyNames"},
+		{&n.unevaluatedItems, s.UnevaluatedItems, "unevaluatedItems"},
+		{&n.unevaluatedProperties, s.UnevaluatedProperties, "unevaluatedProperties"},
+		{&n.contentSchema, s.ContentSchema, "contentSchema"},
+	} {
+		if *f.dst, err = sub(f.src, f.keyword); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(s.Definitions) {
+		if _, err := sub(s.Definitions[name], "$defs", escapePointerToken(name)); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(s.DependentSchemas) {
+		sn, err := sub(s.DependentSchemas[name], "dependentSchemas", escapePointerToken(name))
+		if err != nil {
+			return nil, err
+		}
+		n.dependentSchemas = append(n.dependentSchemas, namedNode{name: name, node: sn})
+	}
+	if s.Properties.Len() > 0 {
+		n.properties = make(map[string]*schemaNode, s.Properties.Len())
+		for _, name := range s.Properties.order {
+			sn, err := sub(s.Properties.values[name], "properties", escapePointerToken(name))
+			if err != nil {
+				return nil, err
+			}
+			n.properties[name] = sn
+		}
+	}
+	for _, pattern := range sortedKeys(s.PatternProperties) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid patternProperties %q at %q: %w", pattern, docPtr, err)
+		}
+		sn, err := sub(s.PatternProperties[pattern], "patternProperties", escapePointerToken(pattern))
+		if err != nil {
+			return nil, err
+		}
+		n.patternProperties = append(n.patternProperties, patternNode{pattern: pattern, re: re, node: sn})
+	}
+
+	if n.unevaluatedItems != nil || n.unevaluatedProperties != nil {
+		c.track = true
+	}
+
+	if err := c.compileAssertions(n, s, docPtr); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (c *compiler) compileAssertions(n *schemaNode, s *Schema, docPtr string) error {
+	if s.Type != "" {
+		n.types = []string{s.Type}
+	} else if len(s.TypeEnhanced) > 0 {
+		n.types = s.TypeEnhanced
+	}
+	if s.Enum != nil {
+		n.enum = make([]any, len(s.Enum))
+		for i, v := range s.Enum {
+			nv, err := normalizeInstance(v)
+			if err != nil {
+				return fmt.Errorf("jsonschema: invalid enum value at %q: %w", docPtr, err)
+			}
+			n.enum[i] = nv
+		}
+	}
+	if s.Const != nil {
+		nv, err := normalizeInstance(s.Const)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid const value at %q: %w", docPtr, err)
+		}
+		n.constant, n.hasConst = nv, true
+	}
+
+	for _, f := range []struct {
+		dst     **numberBound
+		src     jsonv1.Number
+		keyword string
+	}{
+		{&n.multipleOf, s.MultipleOf, "multipleOf"},
+		{&n.maximum, s.Maximum, "maximum"},
+		{&n.exclusiveMaximum, s.ExclusiveMaximum, "exclusiveMaximum"},
+		{&n.minimum, s.Minimum, "minimum"},
+		{&n.exclusiveMinimum, s.ExclusiveMinimum, "exclusiveMinimum"},
+	} {
+		b, err := newNumberBound(f.src)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid %s at %q: %w", f.keyword, docPtr, err)
+		}
+		*f.dst = b
+	}
+	if n.multipleOf != nil && n.multipleOf.rat.Sign() <= 0 {
+		return fmt.Errorf("jsonschema: multipleOf at %q must be greater than 0", docPtr)
+	}
+
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid pattern %q at %q: %w", s.Pattern, docPtr, err)
+		}
+		n.pattern = re
+	}
+
+	if s.Format != "" && c.opts.assertFormat {
+		check, ok := c.opts.formats.Lookup(s.Format)
+		if !ok {
+			return fmt.Errorf("jsonschema: unknown format %q at %q", s.Format, docPtr)
+		}
+		n.format = check
+	}
+	if c.opts.assertContent {
+		if err := c.compileContent(n, s, docPtr); err != nil {
+			return err
+		}
+	}
+
+	n.maxLength = limit(s.MaxLength)
+	n.minLength = limit(s.MinLength)
+	n.maxItems = limit(s.MaxItems)
+	n.minItems = limit(s.MinItems)
+	n.maxContains = limit(s.MaxContains)
+	n.minContains = limit(s.MinContains)
+	n.maxProperties = limit(s.MaxProperties)
+	n.minProperties = limit(s.MinProperties)
+	n.uniqueItems = s.UniqueItems
+	n.required = s.Required
+	for _, name := range sortedKeys(s.DependentRequired) {
+		n.dependentRequired = append(n.dependentRequired, namedStrings{name: name, values: s.DependentRequired[name]})
+	}
+	for _, name := range sortedKeys(s.Extras) {
+		h, ok := lookupKeyword(name)
+		if !ok {
+			continue
+		}
+		eval, err := h.compile(s.Extras[name])
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid %s at %q: %w", name, docPtr, err)
+		}
+		n.keywords = append(n.keywords, namedKeyword{name: name, eval: eval})
+	}
+	return nil
+}
+
+func (c *compiler) compileContent(n *schemaNode, s *Schema, docPtr string) error {
+	var err error
+	if s.ContentEncoding != "" {
+		if n.decodeContent, err = lookupContentDecoder(s.ContentEncoding); err != nil {
+			return fmt.Errorf("jsonschema: %w at %q", err, docPtr)
+		}
+	}
+	if s.ContentMediaType != "" {
+		if n.parseContent, err = lookupContentParser(s.ContentMediaType); err != nil {
+			return fmt.Errorf("jsonschema: %w at %q", err, docPtr)
+		}
+	}
+	return nil
+}
+
+// limit converts an optional count keyword, using -1 for "not set".
+func limit(v *uint64) int64 {
+	if v == nil {
+		return -1
+	}
+	if *v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(*v)
+}
+
+func (c *compiler) register(key string, n *schemaNode) {
+	if _, exists := c.index[key]; !exists {
+		c.index[key] = n
+	}
+}
+
+// link resolves the "$ref" and "$dynamicRef" of every compiled node.
+func (c *compiler) link() error {
+	// documents loaded while linking append their nodes, which are linked too
+	for i := 0; i < len(c.nodes); i++ {
+		n := c.nodes[i]
+		if n.boolean != nil {
+			continue
+		}
+		var err error
+		if n.schema.Ref != "" {
+			if n.ref, err = c.lookup(n, n.schema.Ref); err != nil {
+				return err
+			}
+		}
+		if n.schema.DynamicRef != "" {
+			if n.dynamicRef, err = c.lookup(n, n.schema.DynamicRef); err != nil {
+				return err
+			}
+			// RFC draft-bhutton-json-schema-00 section 8.2.3.2: the dynamic
+			// scope is only used when the initially resolved schema declares
+			// a "$dynamicAnchor" matching the fragment of the reference.
+			_, fragment, _ := strings.Cut(n.schema.DynamicRef, "#")
+			if fragment != "" && !strings.HasPrefix(fragment, "/") && n.dynamicRef.schema.DynamicAnchor == fragment {
+				n.dynamicName = fragment
+				c.dynamicScope = true
+			}
+		}
+	}
+	return nil
+}
+
+func (c *compiler) lookup(n *schemaNode, ref string) (*schemaNode, error) {
+	uri, err := resolveURI(n.base, ref)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $ref %q at %q: %w", ref, n.base+"#"+n.ptr, err)
+	}
+	key := uri
+	if !strings.Contains(key, "#") {
+		key += "#"
+	}
+	if target, ok := c.index[key]; ok {
+		return target, nil
+	}
+	if doc := stripFragment(uri); doc != "" && !c.loaded[doc] {
+		c.loaded[doc] = true
+		s, err := c.opts.registry.Resolve(doc)
+		switch {
+		case err == nil:
+			if _, err := c.compileDocument(s, doc); err != nil {
+				return nil, err
+			}
+			if target, ok := c.index[key]; ok {
+				return target, nil
+			}
+		case !errors.Is(err, ErrSchemaNotFound):
+			return nil, fmt.Errorf("jsonschema: cannot load $ref %q at %q: %w", ref, n.base+"#"+n.ptr, err)
+		}
+	}
+	return nil, fmt.Errorf("jsonschema: cannot resolve $ref %q at %q", ref, n.base+"#"+n.ptr)
+}
+
+// resolveURI resolves a URI reference against a base URI following RFC 3986,
+// normalizing the fragment to its unescaped form.
+func resolveURI(base, ref string) (string, error) {
+	id, err := ID(base).Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	s, fragment, ok := strings.Cut(id.String(), "#")
+	if !ok {
+		return s, nil
+	}
+	fragment, err = url.PathUnescape(fragment)
+	if err != nil {
+		return "", err
+	}
+	return s + "#" + fragment, nil
+}
+
+func stripFragment(uri string) string {
+	if i := strings.IndexByte(uri, '#'); i >= 0 {
+		return uri[:i]
+	}
+	return uri
+}
+
+// typeIn reports whether the instance matches any of the given types.
+func typeIn(types []string, inst any) bool {
+	return slices.ContainsFunc(types, func(t string) bool { return typeMatches(t, inst) })
+}
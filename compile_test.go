@@ -0,0 +1,198 @@
+package jsonschema
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileResolvesReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{
+			name:     "pointer with escaped tokens",
+			schema:   `{"$ref":"#/$defs/a~1b%25c","$defs":{"a/b%c":{"type":"integer"}}}`,
+			instance: `"x"`,
+		},
+		{
+			name:     "pointer into properties",
+			schema:   `{"properties":{"a":{"type":"string"},"b":{"$ref":"#/properties/a"}}}`,
+			instance: `{"b":1}`,
+		},
+		{
+			name:     "absolute reference to root id",
+			schema:   `{"$id":"https://example.com/root","$defs":{"n":{"type":"null"}},"$ref":"https://example.com/root#/$defs/n"}`,
+			instance: `null`,
+			valid:    true,
+		},
+		{
+			name: "embedded resource by relative id",
+			schema: `{
+				"$id": "https://example.com/schemas/root",
+				"$ref": "item",
+				"$defs": {"item": {"$id": "item", "$ref": "#/$defs/name", "$defs": {"name": {"type": "string"}}}}
+			}`,
+			instance: `1`,
+		},
+		{
+			name: "embedded resource in tag URI",
+			schema: `{
+				"$id": "tag:example.com,2024:schemas/root",
+				"$ref": "item#/$defs/n",
+				"$defs": {"item": {"$id": "item", "$defs": {"n": {"type": "null"}}}}
+			}`,
+			instance: `null`,
+			valid:    true,
+		},
+		{
+			name:     "urn id",
+			schema:   `{"$id":"urn:uuid:ee564b8a-7a87-4125-8c96-e9f123d6766f","$defs":{"n":{"type":"null"}},"$ref":"#/$defs/n"}`,
+			instance: `1`,
+		},
+		{
+			name: "anchor in embedded resource",
+			schema: `{
+				"$id": "https://example.com/schemas/root",
+				"$ref": "other#thing",
+				"$defs": {"other": {"$id": "other", "$defs": {"x": {"$anchor": "thing", "minimum": 10}}}}
+			}`,
+			instance: `11`,
+			valid:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Compile(mustUnmarshalSchema(t, tt.schema))
+			require.NoError(t, err)
+			err = v.Validate(mustDecodeInstance(t, tt.instance))
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{"missing definition", `{"$ref":"#/$defs/missing"}`, "cannot resolve $ref"},
+		{"invalid pattern", `{"properties":{"a":{"pattern":"("}}}`, "invalid pattern"},
+		{"invalid pattern property", `{"patternProperties":{"(":true}}`, "invalid patternProperties"},
+		{"zero multipleOf", `{"multipleOf":0}`, "multipleOf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(mustUnmarshalSchema(t, tt.schema))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	_, err := Compile(nil)
+	assert.Error(t, err)
+}
+
+func TestCompileNumericBounds(t *testing.T) {
+	v, err := Compile(mustUnmarshalSchema(t, `{"minimum":0.1,"maximum":18446744073709551616,"multipleOf":0.1}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Validate(0.3))
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `18446744073709551615.9`)))
+	assert.Error(t, v.Validate(mustDecodeInstance(t, `18446744073709551616.1`)))
+	assert.Error(t, v.Validate(0.05))
+	assert.Error(t, v.Validate(0.35))
+	assert.NoError(t, v.Validate(uint64(1)))
+}
+
+func TestValidatorConcurrentUse(t *testing.T) {
+	v, err := Compile(Reflect(&benchOrder{}))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for range 50 {
+				order := newBenchOrder()
+				assert.NoError(t, v.Validate(order))
+				order.Lines[i%len(order.Lines)].Quantity = 0
+				assert.Error(t, v.Validate(order))
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestDynamicRef(t *testing.T) {
+	const tree = `{
+		"$id": "https://example.com/tree",
+		"$dynamicAnchor": "node",
+		"type": "object",
+		"properties": {
+			"data": true,
+			"children": {"type": "array", "items": {"$dynamicRef": "#node"}}
+		}
+	}`
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{"resolves like $ref without outer anchor", tree, `{"children":[{"daat":1}]}`, true},
+		{
+			"outermost dynamic anchor wins",
+			`{"$id":"https://example.com/strict-tree","$dynamicAnchor":"node","$ref":"tree","unevaluatedProperties":false,"$defs":{"tree":` + tree + `}}`,
+			`{"children":[{"daat":1}]}`, false,
+		},
+		{
+			"outermost dynamic anchor accepts valid tree",
+			`{"$id":"https://example.com/strict-tree","$dynamicAnchor":"node","$ref":"tree","unevaluatedProperties":false,"$defs":{"tree":` + tree + `}}`,
+			`{"children":[{"data":1,"children":[]}]}`, true,
+		},
+		{
+			"initial target without dynamic anchor is static",
+			`{"$id":"https://example.com/root","$dynamicAnchor":"x","$ref":"list","$defs":{
+				"string":{"$dynamicAnchor":"x","type":"string"},
+				"list":{"$id":"list","items":{"$dynamicRef":"#x"},"$defs":{"x":{"$anchor":"x","type":"integer"}}}}}`,
+			`[1,2]`, true,
+		},
+		{
+			"dynamic ref to a JSON pointer is static",
+			`{"$id":"https://example.com/root","$dynamicAnchor":"items","$ref":"list","$defs":{
+				"list":{"$id":"list","items":{"$dynamicRef":"#/$defs/items"},"$defs":{"items":{"$dynamicAnchor":"items","type":"integer"}}}}}`,
+			`[1,2]`, true,
+		},
+		{
+			"extended list through the dynamic scope",
+			`{"$id":"https://example.com/strings","$ref":"list","$defs":{
+				"items":{"$dynamicAnchor":"items","type":"string"},
+				"list":{"$id":"list","items":{"$dynamicRef":"#items"},"$defs":{"items":{"$dynamicAnchor":"items"}}}}}`,
+			`["a",1]`, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Compile(mustUnmarshalSchema(t, tt.schema))
+			require.NoError(t, err)
+			err = v.Validate(mustDecodeInstance(t, tt.instance))
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
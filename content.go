@@ -0,0 +1,79 @@
+package jsonschema
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// contentDecoder decodes a string instance encoded with a "contentEncoding".
+type contentDecoder func(value string) ([]byte, error)
+
+// contentParser parses decoded content of a "contentMediaType" into an
+// instance that can be validated against "contentSchema".
+type contentParser func(data []byte) (any, error)
+
+// contentDecoders are the encodings of RFC 2045 section 6.1 and RFC 4648
+// understood when content is asserted. The identity encodings only describe
+// the transport and leave the value untouched.
+//
+// RFC draft-bhutton-json-schema-validation-00 section 8.3
+var contentDecoders = map[string]contentDecoder{
+	"7bit":             nil,
+	"8bit":             nil,
+	"binary":           nil,
+	"base16":           decodeBase16,
+	"base32":           base32.StdEncoding.DecodeString,
+	"base64":           base64.StdEncoding.DecodeString,
+	"quoted-printable": decodeQuotedPrintable,
+}
+
+// contentParsers are the media types whose documents can be parsed and
+// validated. Media types using the "+json" structured syntax suffix of
+// RFC 6839 are parsed as JSON too.
+//
+// RFC draft-bhutton-json-schema-validation-00 section 8.4
+var contentParsers = map[string]contentParser{
+	"application/json": decodeInstance,
+}
+
+// lookupContentDecoder returns the decoder of the encoding, which is nil for
+// the identity encodings.
+func lookupContentDecoder(encoding string) (contentDecoder, error) {
+	decode, ok := contentDecoders[strings.ToLower(encoding)]
+	if !ok {
+		return nil, fmt.Errorf("unknown contentEncoding %q", encoding)
+	}
+	return decode, nil
+}
+
+// lookupContentParser returns the parser of the media type, or nil when the
+// media type is not one that can be parsed.
+func lookupContentParser(mediaType string) (contentParser, error) {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contentMediaType %q: %w", mediaType, err)
+	}
+	if parse, ok := contentParsers[mt]; ok {
+		return parse, nil
+	}
+	if strings.HasSuffix(mt, "+json") {
+		return decodeInstance, nil
+	}
+	return nil, nil
+}
+
+// RFC 4648 section 8
+func decodeBase16(s string) ([]byte, error) {
+	return hex.DecodeString(s)
+}
+
+// RFC 2045 section 6.7
+func decodeQuotedPrintable(s string) ([]byte, error) {
+	return io.ReadAll(quotedprintable.NewReader(strings.NewReader(s)))
+}
@@ -0,0 +1,94 @@
+package jsonschema
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentAssertion(t *testing.T) {
+	schema := `{
+		"properties": {
+			"b64": {"contentEncoding": "base64"},
+			"b32": {"contentEncoding": "base32", "contentMediaType": "application/json"},
+			"qp": {"contentEncoding": "quoted-printable"},
+			"doc": {
+				"contentMediaType": "application/json; charset=utf-8",
+				"contentSchema": {"type": "object", "required": ["id"]}
+			},
+			"enc": {
+				"contentEncoding": "base64",
+				"contentMediaType": "application/problem+json",
+				"contentSchema": {"$ref": "#/$defs/problem"}
+			},
+			"img": {"contentEncoding": "base64", "contentMediaType": "image/png"}
+		},
+		"$defs": {"problem": {"properties": {"status": {"type": "integer"}}}}
+	}`
+	b64 := base64.StdEncoding.EncodeToString
+	tests := []struct {
+		name     string
+		instance string
+		err      string
+	}{
+		{"valid base64", `{"b64":` + strconv.Quote(b64([]byte("hello"))) + `}`, ""},
+		{"invalid base64", `{"b64":"not base64!"}`, `/b64: value is not valid base64`},
+		{"valid base32 json", `{"b32":` + strconv.Quote(base32.StdEncoding.EncodeToString([]byte(`[1,2]`))) + `}`, ""},
+		{"base32 of invalid json", `{"b32":` + strconv.Quote(base32.StdEncoding.EncodeToString([]byte(`[1,`))) + `}`, `/b32: value is not a valid application/json document`},
+		{"valid quoted-printable", `{"qp":"caf=C3=A9"}`, ""},
+		{"invalid quoted-printable", `{"qp":"caf\u0001"}`, `/qp: value is not valid quoted-printable`},
+		{"json matching contentSchema", `{"doc":"{\"id\":1}"}`, ""},
+		{"json failing contentSchema", `{"doc":"{\"name\":1}"}`, `/doc: missing required properties ["id"]`},
+		{"encoded json with reference", `{"enc":` + strconv.Quote(b64([]byte(`{"status":"x"}`))) + `}`, `/enc/status: expected integer but got string (at "/properties/enc/contentSchema/$ref/properties/status/type")`},
+		{"opaque media type is only decoded", `{"img":` + strconv.Quote(b64([]byte{0x89, 'P', 'N', 'G'})) + `}`, ""},
+		{"non-string values are ignored", `{"b64":1,"doc":{}}`, ""},
+	}
+
+	s := mustUnmarshalSchema(t, schema)
+	v, err := Compile(s)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		assert.NoError(t, v.Validate(mustDecodeInstance(t, tt.instance)), "%s: content is an annotation by default", tt.name)
+	}
+
+	v, err = Compile(s, WithContentAssertion())
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(mustDecodeInstance(t, tt.instance))
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestContentUnknownEncoding(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{"contentEncoding":"rot13"}`)
+	_, err := Compile(s)
+	require.NoError(t, err)
+	_, err = Compile(s, WithContentAssertion())
+	assert.ErrorContains(t, err, `unknown contentEncoding "rot13"`)
+}
+
+func TestContentReflected(t *testing.T) {
+	type Payload struct {
+		ID int `json:"id"`
+	}
+	type Message struct {
+		Payload Payload `json:"payload"`
+		Raw     []byte  `json:"raw" jsonschema:"contentMediaType=application/json,contentSchema=Payload"`
+	}
+
+	v, err := Compile(Reflect(&Message{}), WithContentAssertion())
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(&Message{Raw: []byte(`{"id":1}`)}))
+	assert.ErrorContains(t, v.Validate(&Message{Raw: []byte(`{"id":"1"}`)}), "/raw/id: expected integer but got string")
+	assert.ErrorContains(t, v.Validate(&Message{Raw: []byte(`{`)}), "/raw: value is not a valid application/json document")
+}
@@ -0,0 +1,343 @@
+package jsonschema
+
+import (
+	"encoding/json/jsontext"
+	json "encoding/json/v2"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrRecursiveRef is returned, wrapped, by Dereference when a reference
+// cannot be inlined because it designates one of the schemas containing it.
+var ErrRecursiveRef = errors.New("jsonschema: recursive $ref")
+
+type dereferenceOptions struct {
+	registry      *Registry
+	recursiveRefs bool
+}
+
+// DereferenceOption allows for special configuration options when
+// dereferencing a schema.
+type DereferenceOption func(*dereferenceOptions)
+
+// WithDereferenceRegistry sets the registry used to find the documents of
+// references to other schemas instead of DefaultRegistry.
+func WithDereferenceRegistry(r *Registry) DereferenceOption {
+	return func(o *dereferenceOptions) {
+		o.registry = r
+	}
+}
+
+// WithDereferenceLoader resolves references to other schemas with a new
+// Registry using the loaders, see WithDereferenceRegistry.
+func WithDereferenceLoader(loaders ...Loader) DereferenceOption {
+	return WithDereferenceRegistry(NewRegistry(loaders...))
+}
+
+// WithRecursiveRefs makes Dereference keep a "$ref" for the references that
+// designate one of the schemas containing them, instead of failing. The kept
+// reference is a JSON Pointer to the location where the designated schema was
+// inlined, such as "#" or "#/properties/children".
+func WithRecursiveRefs() DereferenceOption {
+	return func(o *dereferenceOptions) {
+		o.recursiveRefs = true
+	}
+}
+
+// Dereference returns a copy of the schema with every "$ref" and
+// "$dynamicRef" replaced by the schema it designates, so that the result can
+// be used by tools that do not support references. References to other
+// documents are resolved with DefaultRegistry, or the registry given by
+// WithDereferenceRegistry or WithDereferenceLoader. "$dynamicRef" is resolved
+// like "$ref", without taking the dynamic scope into account.
+//
+// The designated schema is merged with the keywords next to the reference
+// when they have none in common, or added to their "allOf" otherwise. As they
+// are no longer needed, "$defs" are removed, and so are the "$id", "$anchor"
+// and "$schema" of the sub-schemas. A reference to one of the schemas
+// containing it, as with recursive types, cannot be inlined: Dereference fails
+// with an error wrapping ErrRecursiveRef unless WithRecursiveRefs is used. The
+// schema is not modified.
+func Dereference(s *Schema, opts ...DereferenceOption) (*Schema, error) {
+	if s == nil {
+		return nil, errors.New("jsonschema: cannot dereference a nil schema")
+	}
+	d := &dereferencer{
+		opts:  dereferenceOptions{registry: DefaultRegistry},
+		index: make(map[string]*Schema),
+		bases: make(map[*Schema]string),
+	}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	base, err := resolveURI("", s.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+	}
+	if err := d.add(s, stripFragment(base)); err != nil {
+		return nil, err
+	}
+	return d.dereference(s, stripFragment(base), "", nil)
+}
+
+type dereferencer struct {
+	opts dereferenceOptions
+	// index holds the schemas of the root and of the loaded documents by
+	// absolute URI, see Registry.
+	index map[string]*Schema
+	// bases holds the base URI of every indexed schema, not taking its own
+	// "$id" into account.
+	bases map[*Schema]string
+}
+
+// dereferenceFrame is a schema being dereferenced, inlined at the pointer
+// of the result.
+type dereferenceFrame struct {
+	schema  *Schema
+	pointer string
+}
+
+// add indexes the document identified by the URI.
+func (d *dereferencer) add(s *Schema, uri string) error {
+	if err := indexSchema(d.index, s, uri); err != nil {
+		return err
+	}
+	return recordBases(d.bases, s, uri)
+}
+
+func recordBases(bases map[*Schema]string, s *Schema, base string) error {
+	if s == nil || s.boolean != nil {
+		return nil
+	}
+	bases[s] = base
+	if s.ID != EmptyID {
+		id, err := resolveURI(base, s.ID.String())
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+		}
+		base = stripFragment(id)
+	}
+	for _, sub := range s.subschemas() {
+		if err := recordBases(bases, sub, base); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema designated by the reference and its base URI.
+func (d *dereferencer) resolve(base, ref string) (*Schema, string, error) {
+	uri, err := resolveURI(base, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("jsonschema: invalid $ref %q: %w", ref, err)
+	}
+	if doc := stripFragment(uri); d.index[doc+"#"] == nil {
+		s, err := d.opts.registry.Load(doc)
+		if err != nil {
+			return nil, "", fmt.Errorf("jsonschema: cannot resolve $ref %q: %w", uri, err)
+		}
+		if err := d.add(s, doc); err != nil {
+			return nil, "", err
+		}
+	}
+	s, err := lookupSchema(d.index, uri)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, d.bases[s], nil
+}
+
+// dereference returns the copy of the schema to inline at the pointer of the
+// result, the stack holding the schemas containing it.
+func (d *dereferencer) dereference(s *Schema, base, ptr string, stack []dereferenceFrame) (*Schema, error) {
+	if s.boolean != nil {
+		return s, nil
+	}
+	if s.ID != EmptyID {
+		id, err := resolveURI(base, s.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+		}
+		base = stripFragment(id)
+	}
+	stack = append(stack, dereferenceFrame{s, ptr})
+
+	c := s.shallowCopy()
+	c.Ref, c.DynamicRef, c.Definitions = "", "", nil
+	if ptr != "" {
+		c.Version, c.Vocabulary, c.ID, c.Anchor, c.DynamicAnchor = "", nil, EmptyID, "", ""
+	}
+	for p, sub := range s.subschemas() {
+		if strings.HasPrefix(p, "/$defs/") {
+			continue
+		}
+		sub, err := d.dereference(sub, base, ptr+p, stack)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Set(p, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ref := range []string{s.Ref, s.DynamicRef} {
+		if ref == "" {
+			continue
+		}
+		target, targetBase, err := d.resolve(base, ref)
+		if err != nil {
+			return nil, err
+		}
+		if i := slices.IndexFunc(stack, func(f dereferenceFrame) bool { return f.schema == target }); i >= 0 {
+			if !d.opts.recursiveRefs {
+				return nil, fmt.Errorf("%w: %q at %q", ErrRecursiveRef, ref, ptr)
+			}
+			c.Ref = "#" + (&url.URL{Fragment: stack[i].pointer}).EscapedFragment()
+			continue
+		}
+
+		merge, err := canMerge(c, target)
+		if err != nil {
+			return nil, err
+		}
+		at := ptr
+		if !merge {
+			at = ptr + "/allOf/" + strconv.Itoa(len(c.AllOf))
+		}
+		inlined, err := d.dereference(target, targetBase, at, stack)
+		if err != nil {
+			return nil, err
+		}
+		if !merge {
+			c.AllOf = append(c.AllOf, inlined)
+			continue
+		}
+		if inlined.boolean == nil {
+			// the root keeps its own identity, even when merged at ""
+			inlined.Version, inlined.Vocabulary, inlined.ID, inlined.Anchor, inlined.DynamicAnchor = "", nil, EmptyID, "", ""
+		}
+		if c, err = mergeSchemas(c, inlined); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// dereferencedKeywords are the keywords removed from the inlined schemas.
+var dereferencedKeywords = []string{"$schema", "$vocabulary", "$id", "$anchor", "$dynamicAnchor", "$defs"}
+
+// canMerge reports whether the target of a reference can be merged with the
+// keywords next to the reference: they must have no keyword in common, and no
+// "unevaluatedItems" or "unevaluatedProperties" that would see the keywords of
+// the other.
+func canMerge(c, target *Schema) (bool, error) {
+	if target.boolean != nil {
+		return isEmptySchema(c)
+	}
+	ours, err := schemaMembers(c)
+	if err != nil {
+		return false, err
+	}
+	theirs, err := schemaMembers(target)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range dereferencedKeywords {
+		delete(theirs, name)
+	}
+	if _, ok := theirs["$ref"]; ok {
+		theirs["allOf"] = nil
+	}
+	if _, ok := theirs["$dynamicRef"]; ok {
+		theirs["allOf"], theirs["$ref"] = nil, nil
+	}
+	if len(ours) == 0 {
+		return true, nil
+	}
+	for name := range theirs {
+		if _, ok := ours[name]; ok {
+			return false, nil
+		}
+	}
+	for _, name := range []string{"unevaluatedItems", "unevaluatedProperties"} {
+		_, a := ours[name]
+		_, b := theirs[name]
+		if a || b {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// mergeSchemas returns a schema with the keywords of both schemas, which have
+// none in common.
+func mergeSchemas(a, b *Schema) (*Schema, error) {
+	if ok, err := isEmptySchema(a); err != nil || ok {
+		return b, err
+	}
+	members, err := schemaMembers(a)
+	if err != nil {
+		return nil, err
+	}
+	others, err := schemaMembers(b)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(members, others)
+	data, err := json.Marshal(members, json.Deterministic(true))
+	if err != nil {
+		return nil, err
+	}
+	s := new(Schema)
+	if err := s.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func isEmptySchema(s *Schema) (bool, error) {
+	if s.boolean != nil {
+		return false, nil
+	}
+	members, err := schemaMembers(s)
+	return len(members) == 0, err
+}
+
+// schemaMembers returns the keywords of a schema object.
+func schemaMembers(s *Schema) (map[string]jsontext.Value, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]jsontext.Value)
+	if string(data) == "true" {
+		// empty schemas are serialized as true
+		return members, nil
+	}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// shallowCopy returns a copy of the schema sharing its sub-schemas, but not
+// the maps and lists holding them, so that they can be replaced with Set.
+func (t *Schema) shallowCopy() *Schema {
+	c := *t
+	c.Definitions = maps.Clone(t.Definitions)
+	c.AllOf = slices.Clone(t.AllOf)
+	c.AnyOf = slices.Clone(t.AnyOf)
+	c.OneOf = slices.Clone(t.OneOf)
+	c.PrefixItems = slices.Clone(t.PrefixItems)
+	c.DependentSchemas = maps.Clone(t.DependentSchemas)
+	c.PatternProperties = maps.Clone(t.PatternProperties)
+	if t.Properties != nil {
+		c.Properties = &Properties{order: slices.Clone(t.Properties.order), values: maps.Clone(t.Properties.values)}
+	}
+	return &c
+}
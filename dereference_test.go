@@ -0,0 +1,118 @@
+package jsonschema
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDereference(t *testing.T) {
+	loader := MapLoader{
+		"https://example.com/schemas/address": mustUnmarshalSchema(t, `{
+			"$id": "https://example.com/schemas/address",
+			"type": "object",
+			"properties": {"zip": {"$ref": "#/$defs/zip"}},
+			"$defs": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}}
+		}`),
+	}
+	s := mustUnmarshalSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/schemas/person",
+		"type": "object",
+		"properties": {
+			"name": {"$ref": "#/$defs/name", "description": "Full name"},
+			"nick": {"$ref": "#/$defs/name", "type": "string"},
+			"home": {"$ref": "address"},
+			"any": {"$ref": "#/$defs/any"}
+		},
+		"$defs": {
+			"name": {"type": "string", "minLength": 1},
+			"any": true
+		}
+	}`)
+
+	out, err := Dereference(s, WithDereferenceLoader(loader))
+	require.NoError(t, err)
+	data, err := out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/schemas/person",
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "description": "Full name"},
+			"nick": {"type": "string", "allOf": [{"type": "string", "minLength": 1}]},
+			"home": {
+				"type": "object",
+				"properties": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}}
+			},
+			"any": true
+		}
+	}`, string(data))
+	assert.Equal(t, "#/$defs/name", s.Properties.values["name"].Ref, "the schema must not be modified")
+
+	// a root reference is merged without the identity of its target
+	out, err = Dereference(mustUnmarshalSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/schemas/home",
+		"$ref": "address"
+	}`), WithDereferenceLoader(loader))
+	require.NoError(t, err)
+	data, err = out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/schemas/home",
+		"type": "object",
+		"properties": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}}
+	}`, string(data))
+	out, err = Dereference(mustUnmarshalSchema(t, `{"$ref": "https://example.com/schemas/address"}`), WithDereferenceLoader(loader))
+	require.NoError(t, err)
+	assert.Equal(t, EmptyID, out.ID)
+
+	_, err = Dereference(mustUnmarshalSchema(t, `{"$ref": "https://example.com/schemas/missing"}`), WithDereferenceLoader(loader))
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestDereferenceRecursive(t *testing.T) {
+	data, err := os.ReadFile("fixtures/recursive.json")
+	require.NoError(t, err)
+	s := mustUnmarshalSchema(t, string(data))
+
+	_, err = Dereference(s)
+	assert.ErrorIs(t, err, ErrRecursiveRef)
+	assert.EqualError(t, err, `jsonschema: recursive $ref: "#/$defs/RecursiveExample" at "/properties/children/items"`)
+
+	out, err := Dereference(s, WithRecursiveRefs())
+	require.NoError(t, err)
+	data, err = out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://github.com/invopop/jsonschema/recursive-example",
+		"properties": {
+			"text": {"type": "string"},
+			"children": {"items": {"$ref": "#"}, "type": "array"}
+		},
+		"additionalProperties": false,
+		"type": "object",
+		"required": ["text"]
+	}`, string(data))
+
+	v, err := Compile(out)
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `{"text":"a","children":[{"text":"b","children":[]}]}`)))
+	assert.ErrorContains(t, v.Validate(mustDecodeInstance(t, `{"text":"a","children":[{"children":[]}]}`)), "/children/0")
+
+	nested := mustUnmarshalSchema(t, `{
+		"properties": {"tree": {"$ref": "#/$defs/node"}},
+		"$defs": {"node": {"properties": {"a/b": {"$ref": "#/$defs/node"}}}}
+	}`)
+	out, err = Dereference(nested, WithRecursiveRefs())
+	require.NoError(t, err)
+	data, err = out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"properties": {"tree": {"properties": {"a/b": {"$ref": "#/properties/tree"}}}}}`, string(data))
+}
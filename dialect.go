@@ -0,0 +1,324 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// Dialect identifies a version of JSON Schema by the URI of its meta-schema,
+// as used by the "$schema" keyword.
+type Dialect string
+
+// Dialects supported by Schema.ConvertTo and Reflector.Dialect.
+const (
+	Draft202012 Dialect = "https://json-schema.org/draft/2020-12/schema"
+	Draft07     Dialect = "http://json-schema.org/draft-07/schema#"
+	Draft06     Dialect = "http://json-schema.org/draft-06/schema#"
+	Draft04     Dialect = "http://json-schema.org/draft-04/schema#"
+)
+
+type dialectName struct {
+	dialect Dialect
+	name    string
+}
+
+// dialectNames are the short names of the dialects, from the oldest.
+var dialectNames = []dialectName{
+	{Draft04, "draft-04"},
+	{Draft06, "draft-06"},
+	{Draft07, "draft-07"},
+	{Draft202012, "2020-12"},
+}
+
+// rank orders the dialects from the oldest, returning -1 for an unknown one.
+func (d Dialect) rank() int {
+	return slices.IndexFunc(dialectNames, func(n dialectName) bool { return n.dialect == d })
+}
+
+func (d Dialect) name() string {
+	if i := d.rank(); i >= 0 {
+		return dialectNames[i].name
+	}
+	return string(d)
+}
+
+// Warning reports a keyword that could not be converted without loss.
+type Warning struct {
+	// Location is the JSON Pointer of the schema holding the keyword.
+	Location string
+	Keyword  string
+	Message  string
+}
+
+// String describes the warning.
+func (w Warning) String() string {
+	return fmt.Sprintf("%s/%s: %s", w.Location, escapePointerToken(w.Keyword), w.Message)
+}
+
+// keywordDialects are the keywords that older dialects do not have, with the
+// first dialect supporting them. Keywords rewritten by ConvertTo are not listed.
+var keywordDialects = map[string]Dialect{
+	"contains":              Draft06,
+	"propertyNames":         Draft06,
+	"examples":              Draft06,
+	"$comment":              Draft07,
+	"if":                    Draft07,
+	"then":                  Draft07,
+	"else":                  Draft07,
+	"readOnly":              Draft07,
+	"writeOnly":             Draft07,
+	"contentEncoding":       Draft07,
+	"contentMediaType":      Draft07,
+	"$vocabulary":           Draft202012,
+	"$dynamicRef":           Draft202012,
+	"$dynamicAnchor":        Draft202012,
+	"unevaluatedItems":      Draft202012,
+	"unevaluatedProperties": Draft202012,
+	"maxContains":           Draft202012,
+	"minContains":           Draft202012,
+	"deprecated":            Draft202012,
+	"contentSchema":         Draft202012,
+}
+
+// ConvertTo returns a copy of the schema rewritten for an older dialect, for
+// consumers that do not support 2020-12:
+//
+//   - "$defs" becomes "definitions", "prefixItems" the array form of "items"
+//     and "items" next to it "additionalItems";
+//   - "dependentRequired" and "dependentSchemas" are merged into
+//     "dependencies";
+//   - "$ref" with other keywords is moved into "allOf", as those keywords
+//     would be ignored;
+//   - "$anchor" becomes a fragment-only "$id";
+//   - "$ref" values pointing at the rewritten keywords are updated.
+//
+// For draft-04, "$id" also becomes "id", "const" a single value "enum",
+// numeric "exclusiveMinimum" and "exclusiveMaximum" the boolean form, and
+// boolean schemas their object equivalent.
+//
+// Keywords the dialect does not have, such as "unevaluatedProperties", are
+// kept as they are, which older validators ignore, and reported as warnings.
+// The rewritten keywords are held by Extras, so the result should only be
+// marshalled.
+func (t *Schema) ConvertTo(d Dialect) (*Schema, []Warning, error) {
+	if t == nil {
+		return nil, nil, errors.New("jsonschema: cannot convert a nil schema")
+	}
+	if d.rank() < 0 {
+		return nil, nil, fmt.Errorf("jsonschema: unsupported dialect %q", d)
+	}
+	if d == Draft202012 {
+		return t.Clone(), nil, nil
+	}
+	c := &converter{dialect: d, root: t}
+	s := c.convert(t, "")
+	if s.boolean == nil {
+		s.Version = string(d)
+	}
+	return s, c.warnings, nil
+}
+
+type converter struct {
+	dialect  Dialect
+	root     *Schema
+	warnings []Warning
+}
+
+func (c *converter) warn(ptr, keyword, format string, args ...any) {
+	c.warnings = append(c.warnings, Warning{Location: ptr, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// convert returns the copy of the schema at the pointer rewritten for the
+// dialect.
+func (c *converter) convert(s *Schema, ptr string) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.boolean != nil {
+		if c.dialect != Draft04 {
+			return s
+		}
+		if *s.boolean {
+			return emptySchemaObject()
+		}
+		return &Schema{Not: emptySchemaObject()}
+	}
+
+	c.checkKeywords(s, ptr)
+	n := s.shallowCopy()
+	n.Extras = maps.Clone(s.Extras)
+	for p, sub := range s.subschemas() {
+		_ = n.Set(p, c.convert(sub, ptr+p))
+	}
+
+	if len(n.Definitions) > 0 {
+		n.setExtraValue("definitions", map[string]*Schema(n.Definitions))
+	}
+	n.Definitions = nil
+	if n.PrefixItems != nil {
+		n.setExtraValue("items", n.PrefixItems)
+		if n.Items != nil {
+			n.setExtraValue("additionalItems", n.Items)
+		}
+		n.PrefixItems, n.Items = nil, nil
+	}
+	if len(n.DependentSchemas) > 0 || len(n.DependentRequired) > 0 {
+		deps := make(map[string]any, len(n.DependentSchemas)+len(n.DependentRequired))
+		for name, sub := range n.DependentSchemas {
+			deps[name] = sub
+		}
+		for name, required := range n.DependentRequired {
+			if sub, ok := n.DependentSchemas[name]; ok {
+				deps[name] = &Schema{AllOf: []*Schema{sub, {Required: required}}}
+			} else {
+				deps[name] = required
+			}
+		}
+		n.setExtraValue("dependencies", deps)
+	}
+	n.DependentSchemas, n.DependentRequired = nil, nil
+
+	if n.Anchor != "" {
+		if n.ID == EmptyID {
+			n.ID = ID("#" + n.Anchor)
+		} else {
+			c.warn(ptr, "$anchor", "cannot be expressed next to $id in %s", c.dialect.name())
+			n.setExtraValue("$anchor", n.Anchor)
+		}
+		n.Anchor = ""
+	}
+	if c.dialect == Draft04 {
+		c.convertDraft04(n)
+	}
+
+	if n.Ref != "" {
+		ref := c.convertRef(n.Ref)
+		n.Ref = ""
+		if empty, _ := isEmptySchema(n); empty {
+			n.Ref = ref
+		} else {
+			n.AllOf = append(n.AllOf, &Schema{Ref: ref})
+		}
+	}
+	return n
+}
+
+// checkKeywords reports the keywords of the schema that the dialect does not
+// have.
+func (c *converter) checkKeywords(s *Schema, ptr string) {
+	members, err := schemaMembers(s)
+	if err != nil {
+		return
+	}
+	for _, name := range sortedKeys(members) {
+		since, ok := keywordDialects[name]
+		if !ok || since.rank() <= c.dialect.rank() {
+			continue
+		}
+		c.warn(ptr, name, "not supported by %s, kept as is", c.dialect.name())
+	}
+}
+
+// convertDraft04 rewrites the keywords that changed after draft-04.
+func (c *converter) convertDraft04(n *Schema) {
+	if n.ID != EmptyID {
+		n.setExtraValue("id", n.ID.String())
+		n.ID = EmptyID
+	}
+	if n.Const != nil {
+		if n.Enum == nil {
+			n.Enum = []any{n.Const}
+		} else {
+			n.AllOf = append(n.AllOf, &Schema{Enum: []any{n.Const}})
+		}
+		n.Const = nil
+	}
+	n.Minimum = c.exclusiveBound(n, "exclusiveMinimum", n.Minimum, n.ExclusiveMinimum, -1)
+	n.ExclusiveMinimum = ""
+	n.Maximum = c.exclusiveBound(n, "exclusiveMaximum", n.Maximum, n.ExclusiveMaximum, 1)
+	n.ExclusiveMaximum = ""
+}
+
+// exclusiveBound returns the draft-04 bound for an inclusive and an
+// exclusive limit, setting the boolean exclusive keyword when the exclusive
+// limit is the stricter one. The sign is 1 for a maximum, -1 for a minimum.
+func (c *converter) exclusiveBound(n *Schema, keyword string, inclusive, exclusive jsonv1.Number, sign int) jsonv1.Number {
+	if exclusive == "" {
+		return inclusive
+	}
+	if inclusive != "" {
+		a, okA := toRat(inclusive)
+		b, okB := toRat(exclusive)
+		if okA && okB && a.Cmp(b)*sign < 0 {
+			return inclusive
+		}
+	}
+	n.setExtraValue(keyword, true)
+	return exclusive
+}
+
+// convertRef rewrites the JSON Pointer of a reference for the renamed
+// keywords. The schemas of the root document are used to tell "items" next
+// to "prefixItems", which becomes "additionalItems".
+func (c *converter) convertRef(ref string) string {
+	uri, fragment, ok := strings.Cut(ref, "#")
+	if !ok || !strings.HasPrefix(fragment, "/") {
+		return ref
+	}
+	var node *Schema
+	if uri == "" || uri == c.root.ID.String() {
+		node = c.root
+	}
+	tokens := strings.Split(fragment[1:], "/")
+	for i := 0; i < len(tokens); {
+		keyword := tokens[i]
+		hasNext := i+1 < len(tokens)
+		next := ""
+		if hasNext {
+			next = tokens[i+1]
+		}
+		switch keyword {
+		case "$defs":
+			tokens[i] = "definitions"
+		case "prefixItems":
+			tokens[i] = "items"
+		case "dependentSchemas":
+			tokens[i] = "dependencies"
+		case "items":
+			if node != nil && node.PrefixItems != nil {
+				tokens[i] = "additionalItems"
+			}
+		}
+		consumed := childTokens(keyword, hasNext)
+		if node != nil {
+			name, err := url.PathUnescape(next)
+			if err == nil {
+				node, _ = node.child(unescapePointerToken(keyword), unescapePointerToken(name), hasNext)
+			} else {
+				node = nil
+			}
+		}
+		i += consumed
+	}
+	return uri + "#/" + strings.Join(tokens, "/")
+}
+
+// setExtraValue sets a keyword written as is when marshalling.
+func (t *Schema) setExtraValue(name string, value any) {
+	if t.Extras == nil {
+		t.Extras = make(map[string]any)
+	}
+	t.Extras[name] = value
+}
+
+// emptySchemaObject returns a schema marshalled as "{}" rather than "true",
+// for draft-04 which has no boolean schemas.
+func emptySchemaObject() *Schema {
+	return &Schema{Extras: map[string]any{}}
+}
@@ -0,0 +1,148 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertToDraft07(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/order",
+		"type": "object",
+		"properties": {
+			"line": {"$ref": "#/$defs/line"},
+			"rest": {"$ref": "#/$defs/line/items"},
+			"card": {"$ref": "#/dependentSchemas/card"},
+			"note": {"$anchor": "note", "type": "string", "deprecated": true}
+		},
+		"dependentSchemas": {"card": {"required": ["billing"]}, "gift": {"required": ["to"]}},
+		"dependentRequired": {"gift": ["message"], "coupon": ["code"]},
+		"unevaluatedProperties": false,
+		"$defs": {
+			"line": {
+				"prefixItems": [{"type": "string"}, {"type": "integer", "exclusiveMinimum": 0}],
+				"items": false,
+				"minContains": 1,
+				"contains": true
+			}
+		}
+	}`)
+
+	out, warnings, err := s.ConvertTo(Draft07)
+	require.NoError(t, err)
+	data, err := out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"$id": "https://example.com/order",
+		"type": "object",
+		"properties": {
+			"line": {"$ref": "#/definitions/line"},
+			"rest": {"$ref": "#/definitions/line/additionalItems"},
+			"card": {"$ref": "#/dependencies/card"},
+			"note": {"$id": "#note", "type": "string", "deprecated": true}
+		},
+		"dependencies": {
+			"card": {"required": ["billing"]},
+			"gift": {"allOf": [{"required": ["to"]}, {"required": ["message"]}]},
+			"coupon": ["code"]
+		},
+		"unevaluatedProperties": false,
+		"definitions": {
+			"line": {
+				"items": [{"type": "string"}, {"type": "integer", "exclusiveMinimum": 0}],
+				"additionalItems": false,
+				"minContains": 1,
+				"contains": true
+			}
+		}
+	}`, string(data))
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+	assert.Equal(t, []string{
+		"/unevaluatedProperties: not supported by draft-07, kept as is",
+		"/$defs/line/minContains: not supported by draft-07, kept as is",
+		"/properties/note/deprecated: not supported by draft-07, kept as is",
+	}, got)
+	assert.Equal(t, "#/$defs/line", s.Properties.values["line"].Ref, "the schema must not be modified")
+}
+
+func TestConvertToDraft04(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/range",
+		"properties": {
+			"a": {"exclusiveMinimum": 0, "maximum": 10, "exclusiveMaximum": 20},
+			"b": {"minimum": 5, "exclusiveMinimum": 5, "exclusiveMaximum": 1.5},
+			"c": {"const": "x"},
+			"d": {"const": 1, "enum": [1, 2]},
+			"e": true,
+			"f": false,
+			"g": {"$ref": "#/properties/a", "description": "A again"},
+			"h": {"propertyNames": {"pattern": "^x"}}
+		}
+	}`)
+
+	out, warnings, err := s.ConvertTo(Draft04)
+	require.NoError(t, err)
+	data, err := out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"id": "https://example.com/range",
+		"properties": {
+			"a": {"minimum": 0, "exclusiveMinimum": true, "maximum": 10},
+			"b": {"minimum": 5, "exclusiveMinimum": true, "maximum": 1.5, "exclusiveMaximum": true},
+			"c": {"enum": ["x"]},
+			"d": {"enum": [1, 2], "allOf": [{"enum": [1]}]},
+			"e": {},
+			"f": {"not": {}},
+			"g": {"description": "A again", "allOf": [{"$ref": "#/properties/a"}]},
+			"h": {"propertyNames": {"pattern": "^x"}}
+		}
+	}`, string(data))
+	require.Len(t, warnings, 1)
+	assert.Equal(t, Warning{Location: "/properties/h", Keyword: "propertyNames", Message: "not supported by draft-04, kept as is"}, warnings[0])
+
+	_, _, err = s.ConvertTo("http://json-schema.org/draft-03/schema#")
+	assert.ErrorContains(t, err, "unsupported dialect")
+}
+
+type dynamicTree struct {
+	Value    string `json:"value"`
+	Children []any  `json:"children"`
+}
+
+func (dynamicTree) JSONSchemaExtend(s *Schema) {
+	s.DynamicAnchor = "node"
+	s.Properties.Set("children", &Schema{Type: "array", Items: &Schema{DynamicRef: "#node"}})
+}
+
+func TestReflectorDialectWarnings(t *testing.T) {
+	var got []Warning
+	r := &Reflector{
+		Dialect:        Draft07,
+		DoNotReference: true,
+		DialectWarnings: func(typ reflect.Type, warnings []Warning) {
+			assert.Equal(t, reflect.TypeFor[dynamicTree](), typ)
+			got = append(got, warnings...)
+		},
+	}
+	s := r.Reflect(&dynamicTree{})
+	assert.Equal(t, string(Draft07), s.Version)
+	assert.Equal(t, []Warning{
+		{Location: "", Keyword: "$dynamicAnchor", Message: "not supported by draft-07, kept as is"},
+		{Location: "/properties/children/items", Keyword: "$dynamicRef", Message: "not supported by draft-07, kept as is"},
+	}, got)
+
+	got = nil
+	r = &Reflector{Dialect: Draft07, DialectWarnings: r.DialectWarnings}
+	r.Reflect(&TestUser{})
+	assert.Empty(t, got)
+}
@@ -0,0 +1,557 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// ChangeKind is the kind of a change found by Diff.
+type ChangeKind string
+
+// Kinds of changes found by Diff.
+const (
+	PropertyAdded              ChangeKind = "property added"
+	PropertyRemoved            ChangeKind = "property removed"
+	RequiredAdded              ChangeKind = "required added"
+	RequiredRemoved            ChangeKind = "required removed"
+	TypeNarrowed               ChangeKind = "type narrowed"
+	TypeWidened                ChangeKind = "type widened"
+	TypeChanged                ChangeKind = "type changed"
+	EnumValueAdded             ChangeKind = "enum value added"
+	EnumValueRemoved           ChangeKind = "enum value removed"
+	AdditionalPropertiesClosed ChangeKind = "additionalProperties closed"
+	AdditionalPropertiesOpened ChangeKind = "additionalProperties opened"
+	BoundTightened             ChangeKind = "bound tightened"
+	BoundLoosened              ChangeKind = "bound loosened"
+	ConstraintAdded            ChangeKind = "constraint added"
+	ConstraintRemoved          ChangeKind = "constraint removed"
+	ConstraintChanged          ChangeKind = "constraint changed"
+	SubschemaAdded             ChangeKind = "subschema added"
+	SubschemaRemoved           ChangeKind = "subschema removed"
+	RefChanged                 ChangeKind = "$ref changed"
+)
+
+// Change is a difference between two versions of a schema.
+//
+// A change that rejects instances the old schema accepted breaks writers,
+// which may produce them. A change that accepts instances the old schema
+// rejected breaks readers, which may not handle them. Some changes break
+// both, or neither, such as adding an optional property whose schema
+// accepts any value to an object accepting any other property.
+//
+// A property added or removed is compared with the schema applying to its
+// name on the other side, from "patternProperties" or
+// "additionalProperties", and breaks readers or writers when the changes
+// found there do, which are reported after it.
+type Change struct {
+	// Path is the JSON Pointer of the schema holding the keyword in the new
+	// schema. References are followed, so the path goes through the schema
+	// holding the "$ref" rather than through "$defs".
+	Path    Pointer
+	Keyword string
+	Kind    ChangeKind
+	// Old and New are the values concerned by the change, such as the
+	// bounds, the types or the enum value, when relevant.
+	Old, New any
+
+	BreaksReaders bool
+	BreaksWriters bool
+}
+
+// String describes the change.
+func (c Change) String() string {
+	s := fmt.Sprintf("%s/%s: %s", c.Path, escapePointerToken(c.Keyword), c.Kind)
+	switch {
+	case c.Old != nil && c.New != nil:
+		s += fmt.Sprintf(" (%v -> %v)", c.Old, c.New)
+	case c.Old != nil:
+		s += fmt.Sprintf(" (%v)", c.Old)
+	case c.New != nil:
+		s += fmt.Sprintf(" (%v)", c.New)
+	}
+	return s
+}
+
+// Diff returns the changes from the old to the new version of a schema, for
+// instance between a published schema and the one reflected for the next
+// release. Both schemas are walked together from their root, and the local
+// references, such as "#/$defs/User", are followed on each side. Changes to
+// annotations such as "description" are not reported.
+func Diff(old, new *Schema) []Change {
+	d := &differ{oldRoot: old, newRoot: new, seen: make(map[diffPair]bool)}
+	d.compare("", old, new)
+	return d.changes
+}
+
+type differ struct {
+	oldRoot, newRoot *Schema
+	seen             map[diffPair]bool
+	changes          []Change
+	// polarity is the one of the sub-schemas being compared.
+	polarity polarity
+}
+
+type diffPair struct {
+	a, b     *Schema
+	polarity polarity
+}
+
+// narrowing and widening classify a change as rejecting instances the old
+// schema accepted, or as accepting instances it rejected.
+const (
+	narrowing = 1 << iota
+	widening
+)
+
+// polarity tells how the changes of a sub-schema affect the instances of the
+// whole schema: the same way, inverted under "not", or both ways under "if"
+// whose changes move instances between "then" and "else".
+type polarity int
+
+const (
+	positive polarity = iota
+	negative
+	ambivalent
+)
+
+// apply returns the effect on the whole schema of the effect of a change.
+func (p polarity) apply(effect int) int {
+	switch {
+	case p == negative:
+		return effect&narrowing<<1 | effect&widening>>1
+	case p == ambivalent && effect != 0:
+		return narrowing | widening
+	}
+	return effect
+}
+
+// then returns the polarity of a sub-schema of polarity q in a schema of
+// polarity p.
+func (p polarity) then(q polarity) polarity {
+	switch {
+	case p == ambivalent || q == ambivalent:
+		return ambivalent
+	case p != q:
+		return negative
+	}
+	return positive
+}
+
+func (d *differ) add(path Pointer, keyword string, kind ChangeKind, effect int, old, new any) {
+	effect = d.polarity.apply(effect)
+	d.changes = append(d.changes, Change{
+		Path:          path,
+		Keyword:       keyword,
+		Kind:          kind,
+		Old:           old,
+		New:           new,
+		BreaksReaders: effect&widening != 0,
+		BreaksWriters: effect&narrowing != 0,
+	})
+}
+
+// resolveLocalRef follows the local references of a schema made of a "$ref",
+// and possibly "$defs" and annotations, only.
+func resolveLocalRef(root, s *Schema) *Schema {
+	for range 32 {
+		if s == nil || s.Ref == "" || !strings.HasPrefix(s.Ref, "#") {
+			return s
+		}
+		rest := *s
+		rest.Ref, rest.Definitions = "", nil
+		rest.Title, rest.Description, rest.Comments = "", "", ""
+		if empty, err := isEmptySchema(&rest); err != nil || !empty {
+			return s
+		}
+		fragment, err := url.PathUnescape(s.Ref[1:])
+		if err != nil {
+			return s
+		}
+		target, err := root.At(fragment)
+		if err != nil {
+			return s
+		}
+		s = target
+	}
+	return s
+}
+
+func (d *differ) compare(path Pointer, a, b *Schema) {
+	a, b = resolveLocalRef(d.oldRoot, a), resolveLocalRef(d.newRoot, b)
+	if a == nil {
+		a = TrueSchema
+	}
+	if b == nil {
+		b = TrueSchema
+	}
+	pair := diffPair{a, b, d.polarity}
+	if d.seen[pair] {
+		return
+	}
+	d.seen[pair] = true
+
+	switch {
+	case a.isFalse() && b.isFalse():
+		return
+	case b.isFalse():
+		d.add(path, "", ConstraintAdded, narrowing, true, false)
+		return
+	case a.isFalse():
+		d.add(path, "", ConstraintRemoved, widening, false, true)
+		return
+	}
+	if a.Ref != b.Ref && (a.Ref != "" || b.Ref != "") {
+		d.add(path, "$ref", RefChanged, narrowing|widening, nilIfEmpty(a.Ref), nilIfEmpty(b.Ref))
+	}
+
+	d.compareTypes(path, a, b)
+	d.compareEnum(path, a, b)
+	if (a.Const == nil) != (b.Const == nil) || !jsonEqual(a.Const, b.Const) {
+		d.compareConstraint(path, "const", a.Const, b.Const)
+	}
+	for _, bound := range []struct {
+		keyword string
+		old     any
+		new     any
+		lower   bool
+	}{
+		{"minimum", a.Minimum, b.Minimum, true},
+		{"exclusiveMinimum", a.ExclusiveMinimum, b.ExclusiveMinimum, true},
+		{"maximum", a.Maximum, b.Maximum, false},
+		{"exclusiveMaximum", a.ExclusiveMaximum, b.ExclusiveMaximum, false},
+		{"minLength", a.MinLength, b.MinLength, true},
+		{"maxLength", a.MaxLength, b.MaxLength, false},
+		{"minItems", a.MinItems, b.MinItems, true},
+		{"maxItems", a.MaxItems, b.MaxItems, false},
+		{"minContains", a.MinContains, b.MinContains, true},
+		{"maxContains", a.MaxContains, b.MaxContains, false},
+		{"minProperties", a.MinProperties, b.MinProperties, true},
+		{"maxProperties", a.MaxProperties, b.MaxProperties, false},
+	} {
+		d.compareBound(path, bound.keyword, boundValue(bound.old), boundValue(bound.new), bound.lower)
+	}
+	d.compareMultipleOf(path, a.MultipleOf, b.MultipleOf)
+	if a.Pattern != b.Pattern {
+		d.compareConstraint(path, "pattern", nilIfEmpty(a.Pattern), nilIfEmpty(b.Pattern))
+	}
+	if a.Format != b.Format {
+		d.compareConstraint(path, "format", nilIfEmpty(a.Format), nilIfEmpty(b.Format))
+	}
+	if a.UniqueItems != b.UniqueItems {
+		d.compareConstraint(path, "uniqueItems", nilIfFalse(a.UniqueItems), nilIfFalse(b.UniqueItems))
+	}
+
+	d.compareObject(path, a, b)
+	for _, kw := range []string{"items", "propertyNames", "unevaluatedItems", "unevaluatedProperties"} {
+		d.compareChild(path, kw, a, b)
+	}
+	d.compareOptional(path, "not", a, b, negative)
+	d.compareOptional(path, "if", a, b, ambivalent)
+	if a.If != nil || b.If != nil {
+		d.compareChild(path, "then", a, b)
+		d.compareChild(path, "else", a, b)
+	}
+	d.compareOptional(path, "contains", a, b, positive)
+	d.compareOptional(path, "contentSchema", a, b, positive)
+	d.compareList(path, "prefixItems", a.PrefixItems, b.PrefixItems, narrowing)
+	d.compareList(path, "allOf", a.AllOf, b.AllOf, narrowing)
+	d.compareList(path, "anyOf", a.AnyOf, b.AnyOf, widening)
+	d.compareList(path, "oneOf", a.OneOf, b.OneOf, narrowing|widening)
+	d.compareMap(path, "patternProperties", a.PatternProperties, b.PatternProperties)
+	d.compareMap(path, "dependentSchemas", a.DependentSchemas, b.DependentSchemas)
+}
+
+// compareTypes compares the types, "integer" being a subset of "number".
+func (d *differ) compareTypes(path Pointer, a, b *Schema) {
+	ta, tb := a.types(), b.types()
+	if equalSet(ta, tb) {
+		return
+	}
+	covers := func(types []string, t string) bool {
+		return slices.Contains(types, t) || t == "integer" && slices.Contains(types, "number")
+	}
+	subset := func(x, y []string) bool {
+		if len(y) == 0 {
+			return true
+		}
+		return len(x) > 0 && !slices.ContainsFunc(x, func(t string) bool { return !covers(y, t) })
+	}
+	old, new := typesValue(ta), typesValue(tb)
+	switch {
+	case subset(tb, ta):
+		d.add(path, "type", TypeNarrowed, narrowing, old, new)
+	case subset(ta, tb):
+		d.add(path, "type", TypeWidened, widening, old, new)
+	default:
+		d.add(path, "type", TypeChanged, narrowing|widening, old, new)
+	}
+}
+
+func typesValue(types []string) any {
+	switch len(types) {
+	case 0:
+		return nil
+	case 1:
+		return types[0]
+	}
+	return types
+}
+
+func (d *differ) compareEnum(path Pointer, a, b *Schema) {
+	switch {
+	case a.Enum == nil && b.Enum == nil:
+	case a.Enum == nil:
+		d.add(path, "enum", ConstraintAdded, narrowing, nil, b.Enum)
+	case b.Enum == nil:
+		d.add(path, "enum", ConstraintRemoved, widening, a.Enum, nil)
+	default:
+		contains := func(values []any, v any) bool {
+			return slices.ContainsFunc(values, func(w any) bool { return jsonEqual(v, w) })
+		}
+		for _, v := range a.Enum {
+			if !contains(b.Enum, v) {
+				d.add(path, "enum", EnumValueRemoved, narrowing, v, nil)
+			}
+		}
+		for _, v := range b.Enum {
+			if !contains(a.Enum, v) {
+				d.add(path, "enum", EnumValueAdded, widening, nil, v)
+			}
+		}
+	}
+}
+
+// compareConstraint reports a keyword restricting the instances which was
+// added, removed or changed.
+func (d *differ) compareConstraint(path Pointer, keyword string, old, new any) {
+	switch {
+	case old == nil:
+		d.add(path, keyword, ConstraintAdded, narrowing, nil, new)
+	case new == nil:
+		d.add(path, keyword, ConstraintRemoved, widening, old, nil)
+	default:
+		d.add(path, keyword, ConstraintChanged, narrowing|widening, old, new)
+	}
+}
+
+func (d *differ) compareBound(path Pointer, keyword string, old, new *big.Rat, lower bool) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		d.add(path, keyword, BoundTightened, narrowing, nil, new.RatString())
+		return
+	case new == nil:
+		d.add(path, keyword, BoundLoosened, widening, old.RatString(), nil)
+		return
+	}
+	cmp := new.Cmp(old)
+	if !lower {
+		cmp = -cmp
+	}
+	switch {
+	case cmp > 0:
+		d.add(path, keyword, BoundTightened, narrowing, old.RatString(), new.RatString())
+	case cmp < 0:
+		d.add(path, keyword, BoundLoosened, widening, old.RatString(), new.RatString())
+	}
+}
+
+// compareMultipleOf compares the divisors: a multiple of the old divisor
+// tightens the bound, and one of its divisors loosens it.
+func (d *differ) compareMultipleOf(path Pointer, a, b jsonv1.Number) {
+	x, y := boundValue(a), boundValue(b)
+	divides := func(x, y *big.Rat) bool {
+		return x.Sign() > 0 && y.Sign() > 0 && new(big.Rat).Quo(y, x).IsInt()
+	}
+	switch {
+	case x == nil || y == nil:
+		d.compareBound(path, "multipleOf", x, y, true)
+	case x.Cmp(y) == 0:
+	case divides(x, y):
+		d.add(path, "multipleOf", BoundTightened, narrowing, string(a), string(b))
+	case divides(y, x):
+		d.add(path, "multipleOf", BoundLoosened, widening, string(a), string(b))
+	default:
+		d.add(path, "multipleOf", ConstraintChanged, narrowing|widening, string(a), string(b))
+	}
+}
+
+func boundValue(v any) *big.Rat {
+	switch v := v.(type) {
+	case jsonv1.Number:
+		if v == "" {
+			return nil
+		}
+		r, _ := toRat(v)
+		return r
+	case *uint64:
+		if v == nil {
+			return nil
+		}
+		return new(big.Rat).SetUint64(*v)
+	}
+	return nil
+}
+
+// compareObject compares the properties, "required" and
+// "additionalProperties".
+func (d *differ) compareObject(path Pointer, a, b *Schema) {
+	closedA := a.AdditionalProperties != nil && a.AdditionalProperties.isFalse()
+	closedB := b.AdditionalProperties != nil && b.AdditionalProperties.isFalse()
+
+	var names []string
+	for name := range a.Properties.All() {
+		names = append(names, name)
+	}
+	for name := range b.Properties.All() {
+		if _, ok := a.Properties.Get(name); !ok {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		at := path + "/properties/" + Pointer(escapePointerToken(name))
+		sa, inA := a.Properties.Get(name)
+		sb, inB := b.Properties.Get(name)
+		switch {
+		case inA && inB:
+			d.compare(at, sa, sb)
+		case inB:
+			// the property was constrained by the patterns or the
+			// additional properties of the old schema
+			start := len(d.changes)
+			before, _ := propertySchema(path, a, name)
+			d.compare(at, before, sb)
+			d.summarize(start, path, "properties", PropertyAdded, nil, name)
+		default:
+			start := len(d.changes)
+			after, _ := propertySchema(path, b, name)
+			d.compare(at, sa, after)
+			d.summarize(start, path, "properties", PropertyRemoved, name, nil)
+		}
+	}
+
+	for _, name := range b.Required {
+		if !slices.Contains(a.Required, name) {
+			d.add(path, "required", RequiredAdded, narrowing, nil, name)
+		}
+	}
+	for _, name := range a.Required {
+		if !slices.Contains(b.Required, name) {
+			d.add(path, "required", RequiredRemoved, widening, name, nil)
+		}
+	}
+
+	switch {
+	case closedA && closedB:
+	case closedB:
+		d.add(path, "additionalProperties", AdditionalPropertiesClosed, narrowing, nil, nil)
+	case closedA:
+		d.add(path, "additionalProperties", AdditionalPropertiesOpened, widening, nil, nil)
+	default:
+		d.compareChild(path, "additionalProperties", a, b)
+	}
+}
+
+// summarize inserts, before the changes found from start on, a change
+// breaking readers or writers when any of them does.
+func (d *differ) summarize(start int, path Pointer, keyword string, kind ChangeKind, old, new any) {
+	c := Change{Path: path, Keyword: keyword, Kind: kind, Old: old, New: new}
+	for _, found := range d.changes[start:] {
+		c.BreaksReaders = c.BreaksReaders || found.BreaksReaders
+		c.BreaksWriters = c.BreaksWriters || found.BreaksWriters
+	}
+	d.changes = slices.Insert(d.changes, start, c)
+}
+
+// compareChild compares the sub-schemas of a keyword for which no schema is
+// the same as true.
+func (d *differ) compareChild(path Pointer, keyword string, a, b *Schema) {
+	sa, _ := a.child(keyword, "", false)
+	sb, _ := b.child(keyword, "", false)
+	if sa == nil && sb == nil {
+		return
+	}
+	d.compare(path+"/"+Pointer(keyword), sa, sb)
+}
+
+// compareOptional compares the sub-schemas of a keyword whose absence is not
+// the same as true, and whose changes have the given polarity. Adding the
+// keyword is a narrowing, and removing it a widening, except for "if" which
+// both constrains and relaxes through "then" and "else".
+func (d *differ) compareOptional(path Pointer, keyword string, a, b *Schema, p polarity) {
+	sa, _ := a.child(keyword, "", false)
+	sb, _ := b.child(keyword, "", false)
+	added, removed := narrowing, widening
+	if p == ambivalent {
+		added, removed = narrowing|widening, narrowing|widening
+	}
+	switch {
+	case sa == nil && sb == nil:
+	case sa == nil:
+		d.add(path, keyword, ConstraintAdded, added, nil, nil)
+	case sb == nil:
+		d.add(path, keyword, ConstraintRemoved, removed, nil, nil)
+	default:
+		defer func(prev polarity) { d.polarity = prev }(d.polarity)
+		d.polarity = d.polarity.then(p)
+		d.compare(path+"/"+Pointer(keyword), sa, sb)
+	}
+}
+
+// compareList compares the sub-schemas of a keyword by position, a schema
+// added to or removed from the list having the effect given for an added one,
+// or the opposite one.
+func (d *differ) compareList(path Pointer, keyword string, a, b []*Schema, added int) {
+	for i := range min(len(a), len(b)) {
+		d.compare(path+"/"+Pointer(keyword)+"/"+Pointer(strconv.Itoa(i)), a[i], b[i])
+	}
+	removed := added
+	switch added {
+	case narrowing:
+		removed = widening
+	case widening:
+		removed = narrowing
+	}
+	for i := len(a); i < len(b); i++ {
+		d.add(path, keyword, SubschemaAdded, added, nil, i)
+	}
+	for i := len(b); i < len(a); i++ {
+		d.add(path, keyword, SubschemaRemoved, removed, i, nil)
+	}
+}
+
+func (d *differ) compareMap(path Pointer, keyword string, a, b map[string]*Schema) {
+	for _, name := range sortedKeys(a) {
+		if sb, ok := b[name]; ok {
+			d.compare(path+"/"+Pointer(keyword)+"/"+Pointer(escapePointerToken(name)), a[name], sb)
+		} else {
+			d.add(path, keyword, SubschemaRemoved, widening, name, nil)
+		}
+	}
+	for _, name := range sortedKeys(b) {
+		if _, ok := a[name]; !ok {
+			d.add(path, keyword, SubschemaAdded, narrowing, nil, name)
+		}
+	}
+}
+
+func nilIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nilIfFalse(b bool) any {
+	if !b {
+		return nil
+	}
+	return b
+}
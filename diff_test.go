@@ -0,0 +1,225 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	type change struct {
+		path    Pointer
+		keyword string
+		kind    ChangeKind
+		readers bool
+		writers bool
+	}
+	for _, tc := range []struct {
+		name     string
+		old, new string
+		changes  []change
+	}{
+		{
+			name: "identical",
+			old:  `{"type": "object", "properties": {"a": {"type": "integer", "minimum": 1}}, "description": "x"}`,
+			new:  `{"type": "object", "properties": {"a": {"type": "integer", "minimum": 1.0}}, "description": "y"}`,
+		},
+		{
+			name: "properties",
+			old:  `{"properties": {"a": {}, "b": {}}, "required": ["a"]}`,
+			new:  `{"properties": {"b": {}, "c": {}}, "required": ["b"]}`,
+			changes: []change{
+				{"", "properties", PropertyRemoved, false, false},
+				{"", "properties", PropertyAdded, false, false},
+				{"", "required", RequiredAdded, false, true},
+				{"", "required", RequiredRemoved, true, false},
+			},
+		},
+		{
+			name: "constrained properties",
+			old:  `{"properties": {"a": {}, "b": {"type": "string"}}}`,
+			new:  `{"properties": {"a": {}, "c": {"type": "string"}}}`,
+			changes: []change{
+				{"", "properties", PropertyRemoved, true, false},
+				{"/properties/b", "type", TypeWidened, true, false},
+				{"", "properties", PropertyAdded, false, true},
+				{"/properties/c", "type", TypeNarrowed, false, true},
+			},
+		},
+		{
+			name: "additional and pattern properties",
+			old:  `{"properties": {"a": {}}, "patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": {"type": "string"}}`,
+			new:  `{"properties": {"a": {}, "b": {}, "x-c": {"type": "string", "maxLength": 3}}, "patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": {"type": "string"}}`,
+			changes: []change{
+				{"", "properties", PropertyAdded, true, false},
+				{"/properties/b", "type", TypeWidened, true, false},
+				{"", "properties", PropertyAdded, false, true},
+				{"/properties/x-c", "maxLength", BoundTightened, false, true},
+			},
+		},
+		{
+			name: "closed object",
+			old:  `{"properties": {"a": {}}, "additionalProperties": false}`,
+			new:  `{"properties": {"b": {}}, "additionalProperties": false}`,
+			changes: []change{
+				{"", "properties", PropertyRemoved, false, true},
+				{"/properties/a", "", ConstraintAdded, false, true},
+				{"", "properties", PropertyAdded, true, false},
+				{"/properties/b", "", ConstraintRemoved, true, false},
+			},
+		},
+		{
+			name:    "additionalProperties closed",
+			old:     `{"additionalProperties": {"type": "string"}}`,
+			new:     `{"additionalProperties": false}`,
+			changes: []change{{"", "additionalProperties", AdditionalPropertiesClosed, false, true}},
+		},
+		{
+			name:    "additionalProperties opened",
+			old:     `{"additionalProperties": false}`,
+			new:     `{}`,
+			changes: []change{{"", "additionalProperties", AdditionalPropertiesOpened, true, false}},
+		},
+		{
+			name: "types",
+			old:  `{"properties": {"a": {"type": ["string", "null"]}, "b": {"type": "integer"}, "c": {"type": "string"}, "d": {}}}`,
+			new:  `{"properties": {"a": {"type": "string"}, "b": {"type": "number"}, "c": {"type": "boolean"}, "d": {"type": "object"}}}`,
+			changes: []change{
+				{"/properties/a", "type", TypeNarrowed, false, true},
+				{"/properties/b", "type", TypeWidened, true, false},
+				{"/properties/c", "type", TypeChanged, true, true},
+				{"/properties/d", "type", TypeNarrowed, false, true},
+			},
+		},
+		{
+			name: "enum",
+			old:  `{"enum": ["a", "b", 1]}`,
+			new:  `{"enum": ["a", 1.0, "c"]}`,
+			changes: []change{
+				{"", "enum", EnumValueRemoved, false, true},
+				{"", "enum", EnumValueAdded, true, false},
+			},
+		},
+		{
+			name: "bounds",
+			old:  `{"minimum": 1, "maximum": 10, "maxLength": 5, "minItems": 1, "multipleOf": 2}`,
+			new:  `{"minimum": 2, "maximum": 20, "maxLength": 5, "maxItems": 3, "multipleOf": 4}`,
+			changes: []change{
+				{"", "minimum", BoundTightened, false, true},
+				{"", "maximum", BoundLoosened, true, false},
+				{"", "minItems", BoundLoosened, true, false},
+				{"", "maxItems", BoundTightened, false, true},
+				{"", "multipleOf", BoundTightened, false, true},
+			},
+		},
+		{
+			name: "constraints",
+			old:  `{"pattern": "^a", "format": "email", "const": 1}`,
+			new:  `{"pattern": "^b", "uniqueItems": true}`,
+			changes: []change{
+				{"", "const", ConstraintRemoved, true, false},
+				{"", "pattern", ConstraintChanged, true, true},
+				{"", "format", ConstraintRemoved, true, false},
+				{"", "uniqueItems", ConstraintAdded, false, true},
+			},
+		},
+		{
+			name: "subschemas",
+			old:  `{"items": {"type": "string"}, "anyOf": [{"minLength": 1}], "allOf": [{}, {}]}`,
+			new:  `{"items": {"type": "string", "maxLength": 3}, "anyOf": [{"minLength": 1}, {"type": "null"}], "allOf": [{}]}`,
+			changes: []change{
+				{"/items", "maxLength", BoundTightened, false, true},
+				{"", "allOf", SubschemaRemoved, true, false},
+				{"", "anyOf", SubschemaAdded, true, false},
+			},
+		},
+		{
+			name: "multipleOf",
+			old:  `{"properties": {"a": {"multipleOf": 4}, "b": {"multipleOf": 0.5}, "c": {"multipleOf": 2}}}`,
+			new:  `{"properties": {"a": {"multipleOf": 2}, "b": {"multipleOf": 1.5}, "c": {"multipleOf": 3}}}`,
+			changes: []change{
+				{"/properties/a", "multipleOf", BoundLoosened, true, false},
+				{"/properties/b", "multipleOf", BoundTightened, false, true},
+				{"/properties/c", "multipleOf", ConstraintChanged, true, true},
+			},
+		},
+		{
+			name: "applicators",
+			old:  `{"not": {"type": "string", "maxLength": 3}, "if": {"minimum": 0}, "then": {"maximum": 10}, "contains": {"type": "string"}, "contentSchema": {"required": ["a"]}}`,
+			new:  `{"not": {"type": "string", "maxLength": 5}, "if": {"minimum": 1}, "then": {"maximum": 5}, "contains": {"type": "string", "minLength": 1}, "contentSchema": {"required": []}}`,
+			changes: []change{
+				{"/not", "maxLength", BoundLoosened, false, true},
+				{"/if", "minimum", BoundTightened, true, true},
+				{"/then", "maximum", BoundTightened, false, true},
+				{"/contains", "minLength", BoundTightened, false, true},
+				{"/contentSchema", "required", RequiredRemoved, true, false},
+			},
+		},
+		{
+			name: "applicators added",
+			old:  `{"not": {"not": {"minimum": 1}}, "contains": {}}`,
+			new:  `{"not": {"not": {"minimum": 2}}, "if": {"type": "string"}}`,
+			changes: []change{
+				{"/not/not", "minimum", BoundTightened, false, true},
+				{"", "if", ConstraintAdded, true, true},
+				{"", "contains", ConstraintRemoved, true, false},
+			},
+		},
+		{
+			name:    "false schema",
+			old:     `{"properties": {"a": {"type": "string"}}}`,
+			new:     `{"properties": {"a": false}}`,
+			changes: []change{{"/properties/a", "", ConstraintAdded, false, true}},
+		},
+		{
+			name: "refs",
+			old: `{
+				"$ref": "#/$defs/Pet",
+				"$defs": {
+					"Pet": {"properties": {"owner": {"$ref": "#/$defs/Person"}, "tags": {"items": {"$ref": "#/$defs/Pet"}}}},
+					"Person": {"properties": {"name": {"type": "string"}}}
+				}
+			}`,
+			new: `{
+				"$ref": "#/$defs/Animal",
+				"$defs": {
+					"Animal": {"properties": {"owner": {"$ref": "#/$defs/Owner"}, "tags": {"items": {"$ref": "#/$defs/Animal"}}}},
+					"Owner": {"properties": {"name": {"type": "string", "minLength": 1}}}
+				}
+			}`,
+			changes: []change{
+				{"/properties/owner/properties/name", "minLength", BoundTightened, false, true},
+			},
+		},
+		{
+			name:    "external ref",
+			old:     `{"$ref": "https://example.com/a.json"}`,
+			new:     `{"$ref": "https://example.com/b.json"}`,
+			changes: []change{{"", "$ref", RefChanged, true, true}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []change
+			for _, c := range Diff(mustUnmarshalSchema(t, tc.old), mustUnmarshalSchema(t, tc.new)) {
+				got = append(got, change{c.Path, c.Keyword, c.Kind, c.BreaksReaders, c.BreaksWriters})
+			}
+			assert.Equal(t, tc.changes, got)
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	changes := Diff(
+		mustUnmarshalSchema(t, `{"properties": {"a/b": {"maximum": 10, "enum": [1, 5]}}}`),
+		mustUnmarshalSchema(t, `{"properties": {"a/b": {"maximum": 5, "enum": [5]}, "c": {}}}`),
+	)
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	assert.Equal(t, []string{
+		"/properties/a~1b/enum: enum value removed (1)",
+		"/properties/a~1b/maximum: bound tightened (10 -> 5)",
+		"/properties: property added (c)",
+	}, got)
+}
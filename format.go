@@ -0,0 +1,375 @@
+package jsonschema
+
+import (
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FormatChecker reports whether a string instance conforms to a format.
+type FormatChecker func(value string) bool
+
+// FormatRegistry holds the checkers used to assert the "format" keyword.
+// It is safe for concurrent use.
+type FormatRegistry struct {
+	mu       sync.RWMutex
+	checkers map[string]FormatChecker
+}
+
+// DefaultFormats is the registry used by validators compiled with
+// WithFormatAssertion when no other registry is provided with WithFormats.
+var DefaultFormats = NewFormatRegistry()
+
+// NewFormatRegistry creates a registry pre-populated with checkers for the
+// formats defined by the validation specification.
+//
+// RFC draft-bhutton-json-schema-validation-00 section 7.3
+func NewFormatRegistry() *FormatRegistry {
+	return &FormatRegistry{
+		checkers: map[string]FormatChecker{
+			"date-time":             isDateTime,
+			"date":                  isDate,
+			"time":                  isTime,
+			"duration":              isDuration,
+			"email":                 isEmail,
+			"idn-email":             isIDNEmail,
+			"hostname":              isHostname,
+			"idn-hostname":          isIDNHostname,
+			"ipv4":                  isIPv4,
+			"ipv6":                  isIPv6,
+			"uri":                   isURI,
+			"uri-reference":         isURIReference,
+			"iri":                   isIRI,
+			"iri-reference":         isIRIReference,
+			"uri-template":          isURITemplate,
+			"uuid":                  isUUID,
+			"json-pointer":          isJSONPointer,
+			"relative-json-pointer": isRelativeJSONPointer,
+			"regex":                 isRegex,
+		},
+	}
+}
+
+// Register adds a checker for the named format, replacing any existing one.
+func (r *FormatRegistry) Register(name string, check FormatChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[name] = check
+}
+
+// Lookup returns the checker registered for the named format.
+func (r *FormatRegistry) Lookup(name string) (FormatChecker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	check, ok := r.checkers[name]
+	return check, ok
+}
+
+// RegisterFormat adds a checker for the named format to DefaultFormats.
+func RegisterFormat(name string, check FormatChecker) {
+	DefaultFormats.Register(name, check)
+}
+
+var (
+	dateRegexp     = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	timeRegexp     = regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})(?:\.\d+)?(?:[Zz]|([+-])(\d{2}):(\d{2}))$`)
+	durationRegexp = regexp.MustCompile(`^P(?:\d+W|(?:\d+Y(?:\d+M(?:\d+D)?)?|\d+M(?:\d+D)?|\d+D)(?:T(?:\d+H(?:\d+M(?:\d+S)?)?|\d+M(?:\d+S)?|\d+S))?|T(?:\d+H(?:\d+M(?:\d+S)?)?|\d+M(?:\d+S)?|\d+S))$`)
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	schemeRegexp   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+	dotAtomRegexp  = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+/=?^_`{|}~-]+(?:\\.[A-Za-z0-9!#$%&'*+/=?^_`{|}~-]+)*$")
+)
+
+// RFC 3339 section 5.6
+func isDateTime(s string) bool {
+	i := strings.IndexAny(s, "Tt")
+	if i < 0 {
+		return false
+	}
+	return isDate(s[:i]) && isTime(s[i+1:])
+}
+
+// RFC 3339 section 5.6
+func isDate(s string) bool {
+	m := dateRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	year, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	day, _ := strconv.Atoi(m[3])
+	if month < 1 || month > 12 || day < 1 {
+		return false
+	}
+	days := [...]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}[month-1]
+	if month == 2 && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		days = 29
+	}
+	return day <= days
+}
+
+// RFC 3339 section 5.6, allowing a leap second only at 23:59:60 UTC.
+func isTime(s string) bool {
+	m := timeRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	second, _ := strconv.Atoi(m[3])
+	if hour > 23 || minute > 59 || second > 60 {
+		return false
+	}
+	offset := 0
+	if m[4] != "" {
+		oh, _ := strconv.Atoi(m[5])
+		om, _ := strconv.Atoi(m[6])
+		if oh > 23 || om > 59 {
+			return false
+		}
+		offset = oh*60 + om
+		if m[4] == "-" {
+			offset = -offset
+		}
+	}
+	if second == 60 {
+		utc := ((hour*60+minute-offset)%1440 + 1440) % 1440
+		return utc == 23*60+59
+	}
+	return true
+}
+
+// RFC 3339 appendix A
+func isDuration(s string) bool {
+	return durationRegexp.MatchString(s)
+}
+
+// RFC 5321 section 4.1.2
+func isEmail(s string) bool {
+	return checkEmail(s, false)
+}
+
+// RFC 6531
+func isIDNEmail(s string) bool {
+	return checkEmail(s, true)
+}
+
+func checkEmail(s string, intl bool) bool {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 || at == len(s)-1 {
+		return false
+	}
+	local, domain := s[:at], s[at+1:]
+	if !intl && !isASCII(local) {
+		return false
+	}
+	switch {
+	case len(local) >= 2 && local[0] == '"' && local[len(local)-1] == '"':
+		if !checkQuotedLocal(local[1 : len(local)-1]) {
+			return false
+		}
+	case intl:
+		if !dotAtomRegexp.MatchString(asciiPlaceholder(local)) {
+			return false
+		}
+	default:
+		if !dotAtomRegexp.MatchString(local) {
+			return false
+		}
+	}
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		literal := domain[1 : len(domain)-1]
+		if v6, ok := strings.CutPrefix(literal, "IPv6:"); ok {
+			return isIPv6(v6)
+		}
+		return isIPv4(literal)
+	}
+	if intl {
+		return isIDNHostname(domain)
+	}
+	return isHostname(domain)
+}
+
+func checkQuotedLocal(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+			if i == len(s) {
+				return false
+			}
+		case c == '"' || c < 0x20 || c == 0x7f:
+			return false
+		}
+	}
+	return true
+}
+
+// RFC 1123 section 2.1
+func isHostname(s string) bool {
+	return isASCII(s) && checkHostname(s)
+}
+
+// RFC 5890 section 2.3.2.3, checking the structure of the labels only.
+func isIDNHostname(s string) bool {
+	return utf8.ValidString(s) && checkHostname(asciiPlaceholder(s))
+}
+
+func checkHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !isAlphaNum(c) && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RFC 2673 section 3.2
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+// RFC 4291 section 2.2
+func isIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6() && addr.Zone() == ""
+}
+
+// RFC 3986
+func isURI(s string) bool {
+	return isASCII(s) && checkURI(s, true)
+}
+
+// RFC 3986 section 4.1
+func isURIReference(s string) bool {
+	return isASCII(s) && checkURI(s, false)
+}
+
+// RFC 3987
+func isIRI(s string) bool {
+	return utf8.ValidString(s) && checkURI(asciiPlaceholder(s), true)
+}
+
+// RFC 3987 section 2.2
+func isIRIReference(s string) bool {
+	return utf8.ValidString(s) && checkURI(asciiPlaceholder(s), false)
+}
+
+func checkURI(s string, absolute bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return false
+			}
+			i += 2
+		case isAlphaNum(c) || strings.IndexByte("-._~:/?#[]@!$&'()*+,;=", c) >= 0:
+		default:
+			return false
+		}
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	if absolute {
+		return u.Scheme != "" && schemeRegexp.MatchString(u.Scheme)
+	}
+	return true
+}
+
+// RFC 6570
+func isURITemplate(s string) bool {
+	open := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			if open {
+				return false
+			}
+			open = true
+		case '}':
+			if !open {
+				return false
+			}
+			open = false
+		}
+	}
+	return !open
+}
+
+// RFC 4122 section 3
+func isUUID(s string) bool {
+	return uuidRegexp.MatchString(s)
+}
+
+// RFC 6901 section 3
+func isJSONPointer(s string) bool {
+	if s != "" && s[0] != '/' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '~' && (i+1 == len(s) || (s[i+1] != '0' && s[i+1] != '1')) {
+			return false
+		}
+	}
+	return true
+}
+
+// draft-handrews-relative-json-pointer-01 section 3
+func isRelativeJSONPointer(s string) bool {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 || (i > 1 && s[0] == '0') {
+		return false
+	}
+	return s[i:] == "#" || isJSONPointer(s[i:])
+}
+
+// isRegex checks the pattern against the RE2 syntax used for "pattern", which
+// is close to but not the same as the ECMA-262 dialect of the specification.
+func isRegex(s string) bool {
+	_, err := regexp.Compile(s)
+	return err == nil
+}
+
+// asciiPlaceholder replaces every non-ASCII rune with "a" so that
+// internationalized values can be checked with the ASCII grammar.
+func asciiPlaceholder(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r >= utf8.RuneSelf {
+			r = 'a'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isAlphaNum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,96 @@
+package jsonschema
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		valid  []string
+		bad    []string
+	}{
+		{"date-time", []string{"1963-06-19T08:30:06.283185Z", "1998-12-31t23:59:60z", "2020-01-01T10:00:00+01:30"}, []string{"1990-02-31T15:59:59.123-08:00", "1998-12-31T22:59:60Z", "2020-01-01 10:00:00Z", "2020-01-01T10:00:00"}},
+		{"date", []string{"2020-02-29", "2000-02-29"}, []string{"2021-02-29", "1900-02-29", "2020-13-01", "2020-1-01"}},
+		{"time", []string{"08:30:06Z", "23:59:60Z", "01:29:60+01:30", "12:00:00.5-05:00"}, []string{"08:30:06", "24:00:00Z", "12:00:00+24:00", "22:59:60Z"}},
+		{"duration", []string{"P4DT12H30M5S", "P1Y", "PT1M", "P2W", "P1M2D"}, []string{"P", "PT", "P1Y2D", "P2W1D", "P1D2H", "PT1H2S3M"}},
+		{"email", []string{"joe.bloggs@example.com", `"joe bloggs"@example.com`, "joe@[127.0.0.1]", "joe@[IPv6:::1]"}, []string{"joe.@example.com", "@example.com", "joe@", "jöe@example.com", "joe@-example.com"}},
+		{"idn-email", []string{"실례@실례.테스트", "joe@example.com"}, []string{"2962", "joe@"}},
+		{"hostname", []string{"www.example.com", "example.com.", "xn--4gbwdl.xn--wgbh1c"}, []string{"-a.example.com", "a..b", "a_b.com", "한국.kr"}},
+		{"idn-hostname", []string{"실례.테스트", "example.com"}, []string{"-실례.테스트", "a..b"}},
+		{"ipv4", []string{"192.168.0.1"}, []string{"256.0.0.1", "087.10.0.1", "1.2.3", "::1"}},
+		{"ipv6", []string{"::1", "2001:db8::ff00:42:8329", "::ffff:192.168.0.1"}, []string{"12345::", "fe80::1%eth0", "1.2.3.4", ":::"}},
+		{"uri", []string{"http://example.com/a?b=c#d", "urn:isbn:0451450523", "mailto:joe@example.com"}, []string{"//example.com", "/relative", "http://exa mple.com", "http://example.com/%zz", "1http://x"}},
+		{"uri-reference", []string{"/relative", "#frag", "", "../a?b"}, []string{`\\WINDOWS\fileshare`, "a b", "#%"}},
+		{"iri", []string{"http://ƒøø.ßår/?∂éœ=πîx#πîüx", "http://example.com"}, []string{"/relative", "http://a b"}},
+		{"iri-reference", []string{"//ƒøø.ßår/", "#ƒrägmênt"}, []string{`\\WINDOWS\filëshare`}},
+		{"uri-template", []string{"http://example.com/dictionary/{term:1}/{term}", "/plain"}, []string{"http://example.com/{term", "}"}},
+		{"uuid", []string{"2EB8AA08-AA98-11EA-B4AA-73B441D16380", "00000000-0000-0000-0000-000000000000"}, []string{"2eb8aa08-aa98-11ea-b4aa-73b441d1638", "2eb8aa08aa9811eab4aa73b441d16380"}},
+		{"json-pointer", []string{"", "/foo/0", "/a~1b/~0"}, []string{"foo", "/~2", "/a~"}},
+		{"relative-json-pointer", []string{"0", "1/foo", "2#", "10/a"}, []string{"", "-1", "01/a", "/a", "1#/a"}},
+		{"regex", []string{`^[a-z]+\d*$`}, []string{`^(abc`, `[z-a]`}},
+	}
+
+	for _, tt := range tests {
+		check, ok := DefaultFormats.Lookup(tt.format)
+		require.True(t, ok, tt.format)
+		for _, v := range tt.valid {
+			assert.True(t, check(v), "%s should accept %q", tt.format, v)
+		}
+		for _, v := range tt.bad {
+			assert.False(t, check(v), "%s should reject %q", tt.format, v)
+		}
+	}
+}
+
+func TestFormatAssertion(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{"properties":{"email":{"type":"string","format":"email"},"n":{"format":"email"}}}`)
+	inst := mustDecodeInstance(t, `{"email":"not an email","n":1}`)
+
+	v, err := Compile(s)
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(inst), "format is an annotation by default")
+
+	v, err = Compile(s, WithFormatAssertion())
+	require.NoError(t, err)
+	err = v.Validate(inst)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `/email: value is not a valid "email" (at "/properties/email/format")`)
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `{"email":"joe@example.com","n":1}`)))
+}
+
+func TestFormatUnknown(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{"format":"odd"}`)
+	_, err := Compile(s)
+	require.NoError(t, err)
+	_, err = Compile(s, WithFormatAssertion())
+	assert.ErrorContains(t, err, `unknown format "odd"`)
+}
+
+func TestFormatCustomRegistry(t *testing.T) {
+	data, err := os.ReadFile("fixtures/with_custom_format.json")
+	require.NoError(t, err)
+	s := mustUnmarshalSchema(t, string(data))
+
+	formats := NewFormatRegistry()
+	formats.Register("odd", func(value string) bool {
+		n, err := strconv.Atoi(value)
+		return err == nil && n%2 != 0
+	})
+	v, err := Compile(s, WithFormats(formats))
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `{"dates":["2020-02-29"],"odds":["1","3"]}`)))
+	err = v.Validate(mustDecodeInstance(t, `{"dates":["2021-02-29"],"odds":["1","4"]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `/dates/0: value is not a valid "date"`)
+	assert.Contains(t, err.Error(), `/odds/1: value is not a valid "odd"`)
+
+	_, ok := DefaultFormats.Lookup("odd")
+	assert.False(t, ok, "custom registries must not leak into DefaultFormats")
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
@@ -14,10 +15,24 @@ type ID string
 // EmptyID is used to explicitly define an ID with no value.
 const EmptyID ID = ""
 
-// Validate is used to check if the ID looks like a proper schema.
-// This is done by parsing the ID as a URL and checking it has all the
-// relevant parts.
+// Validate is used to check if the ID is an absolute URI, such as
+// "https://example.com/schemas/user", "urn:uuid:..." or "tag:...".
+// Use ValidateStrict to only accept http(s) URLs.
 func (id ID) Validate() error {
+	u, err := url.Parse(id.String())
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme == "" {
+		return errors.New("not an absolute URI: missing scheme")
+	}
+	return nil
+}
+
+// ValidateStrict is used to check if the ID looks like a proper schema URL.
+// This is done by parsing the ID as a URL and checking it has all the
+// relevant parts: an http or https scheme, a hostname with a dot and a path.
+func (id ID) ValidateStrict() error {
 	u, err := url.Parse(id.String())
 	if err != nil {
 		return fmt.Errorf("invalid URL: %w", err)
@@ -39,18 +54,17 @@ func (id ID) Validate() error {
 
 // Anchor sets the anchor part of the schema URI.
 func (id ID) Anchor(name string) ID {
-	b := id.Base()
-	return ID(b.String() + "#" + name)
+	return id.Base().WithFragment(name)
 }
 
 // Def adds or replaces a definition identifier.
 func (id ID) Def(name string) ID {
-	b := id.Base()
-	return ID(b.String() + "#/$defs/" + name)
+	return id.Base().WithFragment("/$defs/" + name)
 }
 
 // Add appends the provided path to the id, and removes any
-// anchor data that might be there.
+// anchor data that might be there. Unlike Resolve, the path is always
+// appended to the existing one.
 func (id ID) Add(path string) ID {
 	b := id.Base()
 	if !strings.HasPrefix(path, "/") {
@@ -70,7 +84,209 @@ func (id ID) Base() ID {
 	return ID(s)
 }
 
+// Resolve resolves a URI reference against the ID, used as base URI.
+// Unlike Add, it follows RFC 3986: "b" resolved against
+// "https://example.com/schemas/a" is "https://example.com/schemas/b", dot
+// segments are removed and URNs are supported.
+//
+// RFC 3986 section 5.2
+func (id ID) Resolve(ref string) (ID, error) {
+	if _, err := url.Parse(id.String()); err != nil {
+		return EmptyID, fmt.Errorf("invalid URL: %w", err)
+	}
+	if _, err := url.Parse(ref); err != nil {
+		return EmptyID, fmt.Errorf("invalid reference: %w", err)
+	}
+	r := parseURIReference(ref)
+	if id != EmptyID {
+		r = r.resolve(parseURIReference(id.String()))
+	}
+	return ID(r.String()), nil
+}
+
+// Fragment returns the unescaped fragment of the ID, without the "#".
+func (id ID) Fragment() string {
+	_, fragment, ok := strings.Cut(id.String(), "#")
+	if !ok {
+		return ""
+	}
+	if f, err := url.PathUnescape(fragment); err == nil {
+		return f
+	}
+	return fragment
+}
+
+// IsAbsolute reports whether the ID is a URI with a scheme, rather than a
+// relative reference.
+//
+// RFC 3986 section 4.1
+func (id ID) IsAbsolute() bool {
+	return parseURIReference(id.String()).scheme != ""
+}
+
+// WithFragment returns the ID with its fragment replaced, or removed when the
+// fragment is empty.
+func (id ID) WithFragment(fragment string) ID {
+	s, _, _ := strings.Cut(id.String(), "#")
+	if fragment == "" {
+		return ID(s)
+	}
+	return ID(s + "#" + fragment)
+}
+
 // String provides string version of ID
 func (id ID) String() string {
 	return string(id)
 }
+
+// uriRegexp splits a URI reference into its components.
+//
+// RFC 3986 appendix B
+var uriRegexp = regexp.MustCompile(`^(?:([^:/?#]+):)?(//([^/?#]*))?([^?#]*)(\?([^#]*))?(#(.*))?$`)
+
+// uriReference holds the components of a URI reference, keeping apart the
+// components that are empty from the ones that are not defined.
+//
+// RFC 3986 section 3
+type uriReference struct {
+	scheme       string
+	authority    string
+	hasAuthority bool
+	path         string
+	query        string
+	hasQuery     bool
+	fragment     string
+	hasFragment  bool
+}
+
+func parseURIReference(s string) uriReference {
+	m := uriRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return uriReference{path: s}
+	}
+	return uriReference{
+		scheme:       m[1],
+		authority:    m[3],
+		hasAuthority: m[2] != "",
+		path:         m[4],
+		query:        m[6],
+		hasQuery:     m[5] != "",
+		fragment:     m[8],
+		hasFragment:  m[7] != "",
+	}
+}
+
+// resolve transforms the reference into a target URI.
+//
+// RFC 3986 section 5.2.2
+func (r uriReference) resolve(base uriReference) uriReference {
+	var t uriReference
+	switch {
+	case r.scheme != "":
+		t = r
+		t.path = removeDotSegments(r.path)
+	case r.hasAuthority:
+		t = r
+		t.scheme = base.scheme
+		t.path = removeDotSegments(r.path)
+	default:
+		t.scheme = base.scheme
+		t.authority, t.hasAuthority = base.authority, base.hasAuthority
+		switch {
+		case r.path == "":
+			t.path = base.path
+			t.query, t.hasQuery = base.query, base.hasQuery
+			if r.hasQuery {
+				t.query, t.hasQuery = r.query, true
+			}
+		case strings.HasPrefix(r.path, "/"):
+			t.path = removeDotSegments(r.path)
+			t.query, t.hasQuery = r.query, r.hasQuery
+		default:
+			t.path = removeDotSegments(mergePaths(base, r.path))
+			t.query, t.hasQuery = r.query, r.hasQuery
+		}
+	}
+	t.fragment, t.hasFragment = r.fragment, r.hasFragment
+	return t
+}
+
+// mergePaths merges a relative path with the path of the base URI.
+//
+// RFC 3986 section 5.2.3
+func mergePaths(base uriReference, path string) string {
+	if base.hasAuthority && base.path == "" {
+		return "/" + path
+	}
+	if i := strings.LastIndexByte(base.path, '/'); i >= 0 {
+		return base.path[:i+1] + path
+	}
+	return path
+}
+
+// removeDotSegments interprets and removes the "." and ".." segments of a
+// path.
+//
+// RFC 3986 section 5.2.4
+func removeDotSegments(path string) string {
+	var out []string
+	for path != "" {
+		switch {
+		case strings.HasPrefix(path, "../"):
+			path = path[3:]
+		case strings.HasPrefix(path, "./"):
+			path = path[2:]
+		case strings.HasPrefix(path, "/./"):
+			path = path[2:]
+		case path == "/.":
+			path = "/"
+		case strings.HasPrefix(path, "/../"):
+			path = path[3:]
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case path == "/..":
+			path = "/"
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case path == "." || path == "..":
+			path = ""
+		default:
+			i := strings.IndexByte(path[1:], '/')
+			if i < 0 {
+				out = append(out, path)
+				path = ""
+			} else {
+				out = append(out, path[:i+1])
+				path = path[i+1:]
+			}
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// String recomposes the components of the reference.
+//
+// RFC 3986 section 5.3
+func (r uriReference) String() string {
+	var b strings.Builder
+	if r.scheme != "" {
+		b.WriteString(r.scheme)
+		b.WriteByte(':')
+	}
+	if r.hasAuthority {
+		b.WriteString("//")
+		b.WriteString(r.authority)
+	}
+	b.WriteString(r.path)
+	if r.hasQuery {
+		b.WriteByte('?')
+		b.WriteString(r.query)
+	}
+	if r.hasFragment {
+		b.WriteByte('#')
+		b.WriteString(r.fragment)
+	}
+	return b.String()
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/zchee/jsonschema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestID(t *testing.T) {
@@ -27,31 +28,122 @@ func TestID(t *testing.T) {
 }
 
 func TestIDValidation(t *testing.T) {
+	for _, id := range []jsonschema.ID{
+		"https://invopop.com/schema/user",
+		"https://encoding/json",
+		"http://invopop.com",
+		"foor://invopop.com/schema/user",
+		"urn:uuid:ee564b8a-7a87-4125-8c96-e9f123d6766f",
+		"tag:example.com,2024:schemas/user",
+	} {
+		assert.NoError(t, id.Validate(), id)
+	}
+
+	id := jsonschema.ID("time")
+	if assert.Error(t, id.Validate()) {
+		assert.Contains(t, id.Validate().Error(), "not an absolute URI")
+	}
+
+	id = "invopop.com\n/test"
+	if assert.Error(t, id.Validate()) {
+		assert.Contains(t, id.Validate().Error(), "invalid URL")
+	}
+}
+
+func TestIDValidateStrict(t *testing.T) {
 	id := jsonschema.ID("https://invopop.com/schema/user")
-	assert.NoError(t, id.Validate())
+	assert.NoError(t, id.ValidateStrict())
 
 	id = "https://encoding/json"
-	if assert.Error(t, id.Validate()) {
-		assert.Contains(t, id.Validate().Error(), "hostname does not look valid")
+	if assert.Error(t, id.ValidateStrict()) {
+		assert.Contains(t, id.ValidateStrict().Error(), "hostname does not look valid")
 	}
 
 	id = "time"
-	if assert.Error(t, id.Validate()) {
-		assert.Contains(t, id.Validate().Error(), "hostname")
+	if assert.Error(t, id.ValidateStrict()) {
+		assert.Contains(t, id.ValidateStrict().Error(), "hostname")
 	}
 
 	id = "http://invopop.com"
-	if assert.Error(t, id.Validate()) {
-		assert.Contains(t, id.Validate().Error(), "path")
+	if assert.Error(t, id.ValidateStrict()) {
+		assert.Contains(t, id.ValidateStrict().Error(), "path")
 	}
 
 	id = "foor://invopop.com/schema/user"
-	if assert.Error(t, id.Validate()) {
-		assert.Contains(t, id.Validate().Error(), "schema")
+	if assert.Error(t, id.ValidateStrict()) {
+		assert.Contains(t, id.ValidateStrict().Error(), "schema")
 	}
 
 	id = "invopop.com\n/test"
-	if assert.Error(t, id.Validate()) {
-		assert.Contains(t, id.Validate().Error(), "invalid URL")
+	if assert.Error(t, id.ValidateStrict()) {
+		assert.Contains(t, id.ValidateStrict().Error(), "invalid URL")
 	}
 }
+
+func TestIDResolve(t *testing.T) {
+	// RFC 3986 section 5.4
+	base := jsonschema.ID("http://a/b/c/d;p?q")
+	for ref, want := range map[string]string{
+		"g:h":           "g:h",
+		"g":             "http://a/b/c/g",
+		"./g":           "http://a/b/c/g",
+		"g/":            "http://a/b/c/g/",
+		"/g":            "http://a/g",
+		"//g":           "http://g",
+		"?y":            "http://a/b/c/d;p?y",
+		"g?y":           "http://a/b/c/g?y",
+		"#s":            "http://a/b/c/d;p?q#s",
+		"g#s":           "http://a/b/c/g#s",
+		";x":            "http://a/b/c/;x",
+		"":              "http://a/b/c/d;p?q",
+		".":             "http://a/b/c/",
+		"..":            "http://a/b/",
+		"../g":          "http://a/b/g",
+		"../..":         "http://a/",
+		"../../../g":    "http://a/g",
+		"/./g":          "http://a/g",
+		"g.":            "http://a/b/c/g.",
+		"..g":           "http://a/b/c/..g",
+		"./../g":        "http://a/b/g",
+		"g/./h":         "http://a/b/c/g/h",
+		"g/../h":        "http://a/b/c/h",
+		"g;x=1/../y":    "http://a/b/c/y",
+		"g?y/./x":       "http://a/b/c/g?y/./x",
+		"g#s/../x":      "http://a/b/c/g#s/../x",
+		"#/$defs/a%25b": "http://a/b/c/d;p?q#/$defs/a%25b",
+	} {
+		got, err := base.Resolve(ref)
+		require.NoError(t, err, ref)
+		assert.EqualValues(t, want, got, ref)
+	}
+
+	urn := jsonschema.ID("urn:uuid:ee564b8a-7a87-4125-8c96-e9f123d6766f")
+	got, err := urn.Resolve("#/$defs/name")
+	require.NoError(t, err)
+	assert.EqualValues(t, "urn:uuid:ee564b8a-7a87-4125-8c96-e9f123d6766f#/$defs/name", got)
+
+	got, err = jsonschema.ID("tag:example.com,2024:schemas/user").Resolve("address")
+	require.NoError(t, err)
+	assert.EqualValues(t, "tag:example.com,2024:schemas/address", got)
+
+	got, err = jsonschema.ID("https://example.com/a#frag").Resolve("")
+	require.NoError(t, err)
+	assert.EqualValues(t, "https://example.com/a", got)
+
+	_, err = base.Resolve("a\nb")
+	assert.Error(t, err)
+}
+
+func TestIDParts(t *testing.T) {
+	id := jsonschema.ID("https://example.com/schemas/user#/$defs/a%25b")
+	assert.Equal(t, "/$defs/a%b", id.Fragment())
+	assert.True(t, id.IsAbsolute())
+	assert.EqualValues(t, "https://example.com/schemas/user#name", id.WithFragment("name"))
+	assert.EqualValues(t, "https://example.com/schemas/user", id.WithFragment(""))
+
+	assert.Equal(t, "", jsonschema.ID("https://example.com/schemas/user").Fragment())
+	assert.True(t, jsonschema.ID("urn:uuid:ee564b8a-7a87-4125-8c96-e9f123d6766f").IsAbsolute())
+	assert.False(t, jsonschema.ID("schemas/user").IsAbsolute())
+	assert.False(t, jsonschema.ID("//example.com/user").IsAbsolute())
+	assert.EqualValues(t, "urn:example:user#name", jsonschema.ID("urn:example:user").Anchor("name"))
+}
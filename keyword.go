@@ -0,0 +1,106 @@
+package jsonschema
+
+import (
+	json "encoding/json/v2"
+	"fmt"
+	"sync"
+)
+
+// KeywordFunc validates an instance against a compiled custom keyword. The
+// instance is a decoded JSON value: a map[string]any, []any, string, bool, nil
+// or a json.Number. A non-nil error means the instance is invalid and its
+// message is reported as the failure of the keyword.
+type KeywordFunc func(instance any) error
+
+// keywordHandler is the type-erased form of a registered keyword.
+type keywordHandler struct {
+	// decode unmarshals the JSON value of the keyword.
+	decode func(data []byte) (any, error)
+	// compile converts the value found in Schema.Extras, which may come from
+	// a struct tag rather than from JSON, and compiles it.
+	compile func(value any) (KeywordFunc, error)
+}
+
+var (
+	keywordsMu sync.RWMutex
+	keywords   = map[string]keywordHandler{}
+)
+
+// RegisterKeyword adds a custom keyword, such as "x-currency", applied by
+// every Validator compiled afterwards, replacing any keyword registered with
+// the same name. Keywords defined by the specification cannot be overridden:
+// RegisterKeyword panics when given one of their names.
+//
+// The value of the keyword is read from Schema.Extras, where it is stored
+// either by the jsonschema_extras struct tag or by unmarshalling a schema,
+// which decodes the value of registered keywords into T. Values of another
+// type, such as the strings of struct tags, are converted to T through JSON
+// when the schema is compiled.
+//
+// compile is called once per schema using the keyword and returns the
+// function evaluating instances, or an error when the value is invalid, which
+// makes Compile fail.
+func RegisterKeyword[T any](name string, compile func(value T) (KeywordFunc, error)) {
+	if schemaKeywords()[name] {
+		panic(fmt.Errorf("jsonschema: keyword %q is defined by the specification", name))
+	}
+	convert := func(value any) (T, error) {
+		if v, ok := value.(T); ok {
+			return v, nil
+		}
+		var v T
+		data, err := json.Marshal(value)
+		if err != nil {
+			return v, err
+		}
+		err = json.Unmarshal(data, &v)
+		if s, ok := value.(string); ok && err != nil {
+			// Struct tags store every value as a string, try it as JSON.
+			if json.Unmarshal([]byte(s), &v) == nil {
+				return v, nil
+			}
+		}
+		return v, err
+	}
+
+	keywordsMu.Lock()
+	defer keywordsMu.Unlock()
+	keywords[name] = keywordHandler{
+		decode: func(data []byte) (any, error) {
+			var v T
+			err := json.Unmarshal(data, &v)
+			return v, err
+		},
+		compile: func(value any) (KeywordFunc, error) {
+			v, err := convert(value)
+			if err != nil {
+				return nil, err
+			}
+			return compile(v)
+		},
+	}
+}
+
+func lookupKeyword(name string) (keywordHandler, bool) {
+	keywordsMu.RLock()
+	defer keywordsMu.RUnlock()
+	h, ok := keywords[name]
+	return h, ok
+}
+
+// decodeExtra unmarshals the value of a keyword that is not defined by the
+// specification, using the type of the registered keyword if there is one.
+func decodeExtra(name string, data []byte) (any, error) {
+	if h, ok := lookupKeyword(name); ok {
+		v, err := h.decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid value for keyword %q: %w", name, err)
+		}
+		return v, nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
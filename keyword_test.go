@@ -0,0 +1,90 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	RegisterKeyword("x-test-currency", func(allowed []string) (KeywordFunc, error) {
+		if len(allowed) == 0 {
+			return nil, errors.New("at least one currency is required")
+		}
+		return func(instance any) error {
+			s, ok := instance.(string)
+			if !ok || slices.Contains(allowed, s) {
+				return nil
+			}
+			return fmt.Errorf("currency %q is not one of %v", s, allowed)
+		}, nil
+	})
+	RegisterKeyword("x-test-max-digits", func(limit int) (KeywordFunc, error) {
+		return func(instance any) error {
+			if s, ok := instance.(string); ok && len(s) > limit {
+				return fmt.Errorf("more than %d digits", limit)
+			}
+			return nil
+		}, nil
+	})
+}
+
+func TestKeywordUnmarshal(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{"type":"string","x-test-currency":["EUR","USD"],"x-other":{"a":1}}`)
+	assert.Equal(t, map[string]any{
+		"x-test-currency": []string{"EUR", "USD"},
+		"x-other":         map[string]any{"a": 1.0},
+	}, s.Extras)
+
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"string","x-other":{"a":1},"x-test-currency":["EUR","USD"]}`, string(data))
+
+	err = new(Schema).UnmarshalJSON([]byte(`{"x-test-currency":"EUR"}`))
+	assert.ErrorContains(t, err, `invalid value for keyword "x-test-currency"`)
+}
+
+func TestRegisterSpecificationKeyword(t *testing.T) {
+	for _, name := range []string{"type", "minimum", "$ref", "properties"} {
+		assert.PanicsWithError(t, fmt.Sprintf("jsonschema: keyword %q is defined by the specification", name), func() {
+			RegisterKeyword(name, func(any) (KeywordFunc, error) { return nil, nil })
+		})
+	}
+}
+
+func TestKeywordValidate(t *testing.T) {
+	v, err := Compile(mustUnmarshalSchema(t, `{
+		"properties": {
+			"price": {"x-test-currency": ["EUR"]},
+			"other": {"x-other": true}
+		}
+	}`))
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `{"price":"EUR","other":1}`)))
+	assert.EqualError(t, v.Validate(mustDecodeInstance(t, `{"price":"GBP"}`)),
+		`jsonschema: /price: currency "GBP" is not one of [EUR] (at "/properties/price/x-test-currency")`)
+
+	_, err = Compile(mustUnmarshalSchema(t, `{"x-test-currency":[]}`))
+	assert.ErrorContains(t, err, `invalid x-test-currency at "": at least one currency is required`)
+}
+
+func TestKeywordFromTags(t *testing.T) {
+	type Payment struct {
+		Currency string `json:"currency" jsonschema_extras:"x-test-currency=EUR,x-test-currency=USD"`
+		Amount   string `json:"amount" jsonschema_extras:"x-test-max-digits=3"`
+	}
+
+	r := &Reflector{DoNotReference: true}
+	v, err := Compile(r.Reflect(&Payment{}))
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(&Payment{Currency: "USD", Amount: "100"}))
+
+	err = v.Validate(&Payment{Currency: "JPY", Amount: "1000"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `/currency: currency "JPY" is not one of [EUR USD]`)
+	assert.Contains(t, err.Error(), `/amount: more than 3 digits`)
+}
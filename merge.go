@@ -0,0 +1,730 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// ErrUnsatisfiable is wrapped by the errors of Merge and FlattenAllOf when no
+// instance can be valid against both schemas, such as with disjoint types or
+// enums.
+var ErrUnsatisfiable = errors.New("unsatisfiable schema")
+
+// Merge returns a schema accepting the instances valid against both schemas,
+// as "allOf" does. The properties are combined, "required" is the union of
+// both lists, "enum" the intersection of both restricted to the values of
+// the merged types, and the stricter of the numeric, length, item and
+// property bounds is kept. The schemas are not modified and the result
+// shares nothing with them.
+//
+// An error wrapping ErrUnsatisfiable is returned when the combination cannot
+// be satisfied, such as for a required property with disjoint types. Any
+// other error reports keywords which cannot be combined into one schema, such
+// as two different "pattern" or "$ref", or "unevaluatedProperties", whose
+// meaning depends on the schema holding it.
+func Merge(a, b *Schema) (*Schema, error) {
+	return merge("", a, b)
+}
+
+// FlattenAllOf merges the sub-schemas of "allOf" into the schemas holding
+// them, in the whole document, using Merge. An "allOf" sub-schema which
+// cannot be merged is kept in "allOf" of the flattened schema. So are the
+// sub-schemas designated by a local JSON Pointer reference, such as
+// "#/allOf/1", or containing the schema it designates, along with the ones
+// before them, so that the reference keeps designating the same schema. An
+// error wrapping ErrUnsatisfiable is returned when no instance can be valid
+// against a schema and its "allOf". The schemas are modified in place, as
+// with Rewrite, and the flattened root is returned.
+func FlattenAllOf(root *Schema) (*Schema, error) {
+	targets := pointerRefTargets(root)
+	return Rewrite(root, func(path Pointer, s *Schema) (*Schema, error) {
+		if len(s.AllOf) == 0 {
+			return s, nil
+		}
+		pinned := 0
+		for i := range s.AllOf {
+			entry := path + "/allOf/" + Pointer(strconv.Itoa(i))
+			if slices.ContainsFunc(targets, func(t Pointer) bool { return t == entry || strings.HasPrefix(string(t), string(entry)+"/") }) {
+				pinned = i + 1
+			}
+		}
+		// "unevaluatedItems" and "unevaluatedProperties" see the locations
+		// evaluated by "allOf", that is by the merged keywords.
+		base := *s
+		base.AllOf, base.UnevaluatedItems, base.UnevaluatedProperties = nil, nil, nil
+		result := &base
+		var kept []*Schema
+		kept = append(kept, s.AllOf[:pinned]...)
+		for entries := s.AllOf[pinned:]; len(entries) > 0; entries = entries[1:] {
+			m, err := merge(path, result, entries[0])
+			if errors.Is(err, ErrUnsatisfiable) {
+				return nil, err
+			}
+			if err != nil {
+				kept = append(kept, entries[0])
+				continue
+			}
+			result = m
+			entries = append(entries, result.AllOf...)
+			result.AllOf = nil
+		}
+		if result.boolean != nil {
+			result = &Schema{}
+		}
+		result.AllOf = kept
+		result.UnevaluatedItems, result.UnevaluatedProperties = s.UnevaluatedItems, s.UnevaluatedProperties
+		return result, nil
+	})
+}
+
+// pointerRefTargets returns the locations in the document of the schemas
+// designated by the local JSON Pointer references of the document, resolved
+// against the schema resource holding them.
+func pointerRefTargets(root *Schema) []Pointer {
+	resources := map[Pointer]bool{"": true}
+	var targets []Pointer
+	_ = Walk(root, func(path Pointer, s *Schema) error {
+		if s.ID != EmptyID {
+			resources[path] = true
+		}
+		for _, ref := range []string{s.Ref, s.DynamicRef} {
+			if !strings.HasPrefix(ref, "#/") {
+				continue
+			}
+			fragment, err := url.PathUnescape(ref[1:])
+			if err != nil {
+				continue
+			}
+			resource := path
+			for !resources[resource] {
+				resource = resource[:strings.LastIndexByte(string(resource), '/')]
+			}
+			targets = append(targets, resource+Pointer(fragment))
+		}
+		return nil
+	})
+	return targets
+}
+
+func unsatisfiable(path Pointer, format string, args ...any) error {
+	return fmt.Errorf("jsonschema: %w at %q: %s", ErrUnsatisfiable, path, fmt.Sprintf(format, args...))
+}
+
+func mergeConflict(path Pointer, keyword string) error {
+	return fmt.Errorf("jsonschema: cannot merge the %q keywords at %q", keyword, path)
+}
+
+func merge(path Pointer, a, b *Schema) (*Schema, error) {
+	var m *Schema
+	switch {
+	case a == nil:
+		m = b.Clone()
+	case b == nil:
+		m = a.Clone()
+	case a.isFalse() || b.isFalse():
+		return nil, unsatisfiable(path, "false schema")
+	case b.isTrue():
+		m = a.Clone()
+	case a.isTrue():
+		m = b.Clone()
+	default:
+		m, b = a.Clone(), b.Clone()
+		for _, f := range []func(Pointer, *Schema, *Schema) error{
+			mergeIdentifiers,
+			mergeAnnotations,
+			mergeApplicators,
+			mergeArrays,
+			mergeObjects,
+			mergeValues,
+		} {
+			if err := f(path, m, b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if m == nil || m.boolean != nil {
+		return m, nil
+	}
+	if err := checkSatisfiable(path, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mergeOptional merges schemas applying to a location which may be absent,
+// such as a property which is not required, an unsatisfiable combination
+// forbidding it.
+func mergeOptional(path Pointer, a, b *Schema) (*Schema, error) {
+	s, err := merge(path, a, b)
+	if errors.Is(err, ErrUnsatisfiable) {
+		return FalseSchema.Clone(), nil
+	}
+	return s, err
+}
+
+// mergeString merges a keyword which both schemas must have the same value
+// for, if any.
+func mergeString(path Pointer, keyword string, dst *string, v string) error {
+	switch {
+	case v == "" || *dst == v:
+	case *dst == "":
+		*dst = v
+	default:
+		return mergeConflict(path, keyword)
+	}
+	return nil
+}
+
+// mergeSame merges a sub-schema which both schemas must have the same value
+// for, if any.
+func mergeSame(path Pointer, keyword string, dst **Schema, s *Schema) error {
+	switch {
+	case s == nil || Equal(*dst, s):
+	case *dst == nil:
+		*dst = s
+	default:
+		return mergeConflict(path, keyword)
+	}
+	return nil
+}
+
+func mergeIdentifiers(path Pointer, m, b *Schema) error {
+	// The identifiers of b cannot designate the merged schema, which
+	// replaces m.
+	if b.ID != "" && b.ID != m.ID {
+		return mergeConflict(path, "$id")
+	}
+	if b.Anchor != "" && b.Anchor != m.Anchor {
+		return mergeConflict(path, "$anchor")
+	}
+	if b.DynamicAnchor != "" && b.DynamicAnchor != m.DynamicAnchor {
+		return mergeConflict(path, "$dynamicAnchor")
+	}
+	if b.Vocabulary != nil && !maps.Equal(m.Vocabulary, b.Vocabulary) {
+		return mergeConflict(path, "$vocabulary")
+	}
+	for _, kw := range []struct {
+		name string
+		dst  *string
+		v    string
+	}{
+		{"$schema", &m.Version, b.Version},
+		{"$ref", &m.Ref, b.Ref},
+		{"$dynamicRef", &m.DynamicRef, b.DynamicRef},
+	} {
+		if err := mergeString(path, kw.name, kw.dst, kw.v); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(b.Definitions) {
+		if s, ok := m.Definitions[name]; ok && !Equal(s, b.Definitions[name]) {
+			return mergeConflict(path, "$defs")
+		}
+		setEntry((*map[string]*Schema)(&m.Definitions), name, b.Definitions[name])
+	}
+	return nil
+}
+
+// mergeAnnotations keeps the annotations of m, completed with the ones of b.
+func mergeAnnotations(path Pointer, m, b *Schema) error {
+	for _, kw := range []struct {
+		dst *string
+		v   string
+	}{
+		{&m.Comments, b.Comments},
+		{&m.Title, b.Title},
+		{&m.Description, b.Description},
+	} {
+		if *kw.dst == "" {
+			*kw.dst = kw.v
+		}
+	}
+	if m.Default == nil {
+		m.Default = b.Default
+	}
+	if m.Examples == nil {
+		m.Examples = b.Examples
+	}
+	m.Deprecated = m.Deprecated || b.Deprecated
+	m.ReadOnly = m.ReadOnly || b.ReadOnly
+	m.WriteOnly = m.WriteOnly || b.WriteOnly
+	for _, name := range sortedKeys(b.Extras) {
+		if v, ok := m.Extras[name]; ok && !jsonEqual(v, b.Extras[name]) {
+			return mergeConflict(path, name)
+		}
+		m.setExtraValue(name, b.Extras[name])
+	}
+	return nil
+}
+
+func mergeApplicators(path Pointer, m, b *Schema) error {
+	if m.UnevaluatedItems != nil || b.UnevaluatedItems != nil {
+		return mergeConflict(path, "unevaluatedItems")
+	}
+	if m.UnevaluatedProperties != nil || b.UnevaluatedProperties != nil {
+		return mergeConflict(path, "unevaluatedProperties")
+	}
+	m.AllOf = append(m.AllOf, b.AllOf...)
+	for _, kw := range []struct {
+		name string
+		dst  *[]*Schema
+		list []*Schema
+	}{
+		{"anyOf", &m.AnyOf, b.AnyOf},
+		{"oneOf", &m.OneOf, b.OneOf},
+	} {
+		switch {
+		case len(kw.list) == 0 || slices.EqualFunc(*kw.dst, kw.list, Equal):
+		case len(*kw.dst) == 0:
+			*kw.dst = kw.list
+		default:
+			return mergeConflict(path, kw.name)
+		}
+	}
+	switch {
+	case b.Not == nil || Equal(m.Not, b.Not):
+	case m.Not == nil:
+		m.Not = b.Not
+	default:
+		// not A and not B is not (A or B)
+		m.Not = &Schema{AnyOf: []*Schema{m.Not, b.Not}}
+	}
+	switch {
+	case b.If == nil && b.Then == nil && b.Else == nil:
+	case m.If == nil && m.Then == nil && m.Else == nil:
+		m.If, m.Then, m.Else = b.If, b.Then, b.Else
+	case !Equal(m.If, b.If) || !Equal(m.Then, b.Then) || !Equal(m.Else, b.Else):
+		return mergeConflict(path, "if")
+	}
+	for _, name := range sortedKeys(b.DependentSchemas) {
+		s, err := mergeOptional(path+"/dependentSchemas/"+Pointer(escapePointerToken(name)), m.DependentSchemas[name], b.DependentSchemas[name])
+		if err != nil {
+			return err
+		}
+		setEntry(&m.DependentSchemas, name, s)
+	}
+	if err := mergeSame(path, "contentSchema", &m.ContentSchema, b.ContentSchema); err != nil {
+		return err
+	}
+	if m.PropertyNames != nil || b.PropertyNames != nil {
+		s, err := mergeOptional(path+"/propertyNames", m.PropertyNames, b.PropertyNames)
+		if err != nil {
+			return err
+		}
+		m.PropertyNames = s
+	}
+	return nil
+}
+
+func mergeArrays(path Pointer, m, b *Schema) error {
+	// The items at each position are constrained by "prefixItems", or by
+	// "items" after them.
+	itemAt := func(s *Schema, i int) *Schema {
+		if i < len(s.PrefixItems) {
+			return s.PrefixItems[i]
+		}
+		return s.Items
+	}
+	var prefixItems []*Schema
+	for i := range max(len(m.PrefixItems), len(b.PrefixItems)) {
+		s, err := mergeOptional(path+"/prefixItems/"+Pointer(strconv.Itoa(i)), itemAt(m, i), itemAt(b, i))
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			s = &Schema{}
+		}
+		prefixItems = append(prefixItems, s)
+	}
+	m.PrefixItems = prefixItems
+	if m.Items != nil || b.Items != nil {
+		s, err := mergeOptional(path+"/items", m.Items, b.Items)
+		if err != nil {
+			return err
+		}
+		m.Items = s
+	}
+	if b.Contains != nil {
+		if err := mergeSame(path, "contains", &m.Contains, b.Contains); err != nil {
+			return err
+		}
+		m.MinContains = stricterUint(m.MinContains, b.MinContains, true)
+		m.MaxContains = stricterUint(m.MaxContains, b.MaxContains, false)
+	}
+	m.MinItems = stricterUint(m.MinItems, b.MinItems, true)
+	m.MaxItems = stricterUint(m.MaxItems, b.MaxItems, false)
+	m.UniqueItems = m.UniqueItems || b.UniqueItems
+	return nil
+}
+
+func mergeObjects(path Pointer, m, b *Schema) error {
+	// "additionalProperties" applies to the names matching none of the
+	// patterns of its schema, which cannot be kept apart from the patterns
+	// of the other schema.
+	for _, pair := range [][2]*Schema{{m, b}, {b, m}} {
+		if pair[0].AdditionalProperties == nil {
+			continue
+		}
+		for name := range pair[1].PatternProperties {
+			if _, ok := pair[0].PatternProperties[name]; !ok {
+				return mergeConflict(path, "additionalProperties")
+			}
+		}
+	}
+
+	required := slices.Clone(m.Required)
+	for _, name := range b.Required {
+		if !slices.Contains(required, name) {
+			required = append(required, name)
+		}
+	}
+	if m.Properties != nil || b.Properties != nil {
+		var names []string
+		for name := range m.Properties.All() {
+			names = append(names, name)
+		}
+		for name := range b.Properties.All() {
+			if _, ok := m.Properties.Get(name); !ok {
+				names = append(names, name)
+			}
+		}
+		properties := NewPropertiesCap(len(names))
+		for _, name := range names {
+			at := path + "/properties/" + Pointer(escapePointerToken(name))
+			sm, err := propertySchema(at, m, name)
+			if err != nil {
+				return err
+			}
+			sb, err := propertySchema(at, b, name)
+			if err != nil {
+				return err
+			}
+			var s *Schema
+			if slices.Contains(required, name) {
+				s, err = merge(at, sm, sb)
+			} else {
+				s, err = mergeOptional(at, sm, sb)
+			}
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				s = &Schema{}
+			}
+			properties.Set(name, s)
+		}
+		m.Properties = properties
+	}
+	for _, name := range sortedKeys(b.PatternProperties) {
+		s, err := mergeOptional(path+"/patternProperties/"+Pointer(escapePointerToken(name)), m.PatternProperties[name], b.PatternProperties[name])
+		if err != nil {
+			return err
+		}
+		setEntry(&m.PatternProperties, name, s)
+	}
+	if m.AdditionalProperties != nil || b.AdditionalProperties != nil {
+		s, err := mergeOptional(path+"/additionalProperties", m.AdditionalProperties, b.AdditionalProperties)
+		if err != nil {
+			return err
+		}
+		m.AdditionalProperties = s
+	}
+
+	m.Required = required
+	for _, name := range sortedKeys(b.DependentRequired) {
+		if m.DependentRequired == nil {
+			m.DependentRequired = make(map[string][]string)
+		}
+		for _, dependent := range b.DependentRequired[name] {
+			if !slices.Contains(m.DependentRequired[name], dependent) {
+				m.DependentRequired[name] = append(m.DependentRequired[name], dependent)
+			}
+		}
+	}
+	m.MinProperties = stricterUint(m.MinProperties, b.MinProperties, true)
+	m.MaxProperties = stricterUint(m.MaxProperties, b.MaxProperties, false)
+	return nil
+}
+
+// propertySchema returns the schema constraining a property: the one of
+// "properties", or else the ones of the matching "patternProperties", or else
+// "additionalProperties". A nil schema does not constrain the property.
+func propertySchema(path Pointer, s *Schema, name string) (*Schema, error) {
+	if p, ok := s.Properties.Get(name); ok {
+		return p, nil
+	}
+	var result *Schema
+	matched := false
+	for _, pattern := range sortedKeys(s.PatternProperties) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, mergeConflict(path, "patternProperties")
+		}
+		if !re.MatchString(name) {
+			continue
+		}
+		matched = true
+		if result, err = mergeOptional(path, result, s.PatternProperties[pattern]); err != nil {
+			return nil, err
+		}
+	}
+	if !matched {
+		return s.AdditionalProperties, nil
+	}
+	return result, nil
+}
+
+func mergeValues(path Pointer, m, b *Schema) error {
+	if tb := b.types(); len(tb) > 0 {
+		types := tb
+		if tm := m.types(); len(tm) > 0 {
+			types = intersectTypes(tm, tb)
+			if len(types) == 0 {
+				return unsatisfiable(path, "types %q and %q are disjoint", tm, tb)
+			}
+		}
+		m.Type, m.TypeEnhanced = "", nil
+		if len(types) == 1 {
+			m.Type = types[0]
+		} else {
+			m.TypeEnhanced = types
+		}
+	}
+
+	if b.Enum != nil {
+		if m.Enum == nil {
+			m.Enum = b.Enum
+		} else {
+			var values []any
+			for _, v := range m.Enum {
+				if slices.ContainsFunc(b.Enum, func(w any) bool { return jsonEqual(v, w) }) {
+					values = append(values, v)
+				}
+			}
+			if len(values) == 0 {
+				return unsatisfiable(path, "enums have no value in common")
+			}
+			m.Enum = values
+		}
+	}
+	if b.Const != nil {
+		if m.Const != nil && !jsonEqual(m.Const, b.Const) {
+			return unsatisfiable(path, "consts differ")
+		}
+		m.Const = b.Const
+	}
+	if types := m.types(); m.Enum != nil && len(types) > 0 {
+		values := slices.DeleteFunc(slices.Clone(m.Enum), func(v any) bool { return !ofTypes(types, v) })
+		if len(values) == 0 {
+			return unsatisfiable(path, "no enum value is of the types %q", types)
+		}
+		m.Enum = values
+	}
+
+	m.Minimum = stricterNumber(m.Minimum, b.Minimum, true)
+	m.ExclusiveMinimum = stricterNumber(m.ExclusiveMinimum, b.ExclusiveMinimum, true)
+	m.Maximum = stricterNumber(m.Maximum, b.Maximum, false)
+	m.ExclusiveMaximum = stricterNumber(m.ExclusiveMaximum, b.ExclusiveMaximum, false)
+	if b.MultipleOf != "" {
+		multipleOf, ok := lcmNumber(m.MultipleOf, b.MultipleOf)
+		if !ok {
+			return mergeConflict(path, "multipleOf")
+		}
+		m.MultipleOf = multipleOf
+	}
+	m.MinLength = stricterUint(m.MinLength, b.MinLength, true)
+	m.MaxLength = stricterUint(m.MaxLength, b.MaxLength, false)
+	for _, kw := range []struct {
+		name string
+		dst  *string
+		v    string
+	}{
+		{"pattern", &m.Pattern, b.Pattern},
+		{"format", &m.Format, b.Format},
+		{"contentEncoding", &m.ContentEncoding, b.ContentEncoding},
+		{"contentMediaType", &m.ContentMediaType, b.ContentMediaType},
+	} {
+		if err := mergeString(path, kw.name, kw.dst, kw.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// intersectTypes returns the types in both lists, "integer" being a subset
+// of "number".
+func intersectTypes(x, y []string) []string {
+	var types []string
+	add := func(t string) {
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	for _, t := range x {
+		switch {
+		case slices.Contains(y, t):
+			add(t)
+		case t == "integer" && slices.Contains(y, "number"),
+			t == "number" && slices.Contains(y, "integer"):
+			add("integer")
+		}
+	}
+	return types
+}
+
+// checkSatisfiable reports the bounds that no instance can satisfy. Bounds
+// only apply to instances of some types, so they are contradictory only when
+// the schema accepts no other type.
+func checkSatisfiable(path Pointer, s *Schema) error {
+	types := s.types()
+	only := func(kinds ...string) bool {
+		return len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return !slices.Contains(kinds, t) })
+	}
+	if s.Const != nil && s.Enum != nil && !slices.ContainsFunc(s.Enum, func(v any) bool { return jsonEqual(v, s.Const) }) {
+		return unsatisfiable(path, "const is not in enum")
+	}
+	if s.Const != nil && !ofTypes(types, s.Const) {
+		return unsatisfiable(path, "const is not of the types %q", types)
+	}
+	if s.Enum != nil && !slices.ContainsFunc(s.Enum, func(v any) bool { return ofTypes(types, v) }) {
+		return unsatisfiable(path, "no enum value is of the types %q", types)
+	}
+	if only("number", "integer") {
+		lower, lowerExclusive := s.Minimum, false
+		if s.ExclusiveMinimum != "" && (lower == "" || compareNumbers(s.ExclusiveMinimum, lower) >= 0) {
+			lower, lowerExclusive = s.ExclusiveMinimum, true
+		}
+		upper, upperExclusive := s.Maximum, false
+		if s.ExclusiveMaximum != "" && (upper == "" || compareNumbers(s.ExclusiveMaximum, upper) <= 0) {
+			upper, upperExclusive = s.ExclusiveMaximum, true
+		}
+		if lower != "" && upper != "" {
+			cmp := compareNumbers(lower, upper)
+			if cmp > 0 || cmp == 0 && (lowerExclusive || upperExclusive) {
+				return unsatisfiable(path, "no number is between %s and %s", lower, upper)
+			}
+		}
+	}
+	for _, bound := range []struct {
+		kind     string
+		min, max *uint64
+		name     string
+	}{
+		{"string", s.MinLength, s.MaxLength, "length"},
+		{"array", s.MinItems, s.MaxItems, "items"},
+		{"object", s.MinProperties, s.MaxProperties, "properties"},
+	} {
+		if only(bound.kind) && bound.min != nil && bound.max != nil && *bound.min > *bound.max {
+			return unsatisfiable(path, "minimum %s %d is greater than maximum %d", bound.name, *bound.min, *bound.max)
+		}
+	}
+	// as for the properties merged in mergeObjects, a required property
+	// that cannot be valid makes the schema unsatisfiable
+	for _, name := range s.Required {
+		at := path + "/properties/" + Pointer(escapePointerToken(name))
+		if p, err := propertySchema(at, s, name); err == nil && p != nil && p.isFalse() {
+			return unsatisfiable(at, "required property %q is forbidden", name)
+		}
+	}
+	if only("object") && s.MaxProperties != nil && uint64(len(s.Required)) > *s.MaxProperties {
+		return unsatisfiable(path, "%d properties are required but at most %d are allowed", len(s.Required), *s.MaxProperties)
+	}
+	return nil
+}
+
+// ofTypes reports whether the value of "enum" or "const" has one of the
+// types, any value having one of no types.
+func ofTypes(types []string, v any) bool {
+	if len(types) == 0 {
+		return true
+	}
+	if n, err := normalizeInstance(v); err == nil {
+		v = n
+	}
+	return slices.ContainsFunc(types, func(t string) bool { return typeMatches(t, v) })
+}
+
+func compareNumbers(x, y jsonv1.Number) int {
+	rx, _ := toRat(x)
+	ry, _ := toRat(y)
+	if rx == nil || ry == nil {
+		return 0
+	}
+	return rx.Cmp(ry)
+}
+
+// stricterNumber returns the greater of two lower bounds, or the lesser of
+// two upper bounds.
+func stricterNumber(x, y jsonv1.Number, lower bool) jsonv1.Number {
+	switch {
+	case x == "":
+		return y
+	case y == "":
+		return x
+	}
+	cmp := compareNumbers(x, y)
+	if cmp < 0 == lower {
+		return y
+	}
+	return x
+}
+
+func stricterUint(x, y *uint64, lower bool) *uint64 {
+	switch {
+	case x == nil:
+		return y
+	case y == nil:
+		return x
+	}
+	if *x < *y == lower {
+		return y
+	}
+	return x
+}
+
+// lcmNumber returns the least common multiple of two divisors, if they have
+// one.
+func lcmNumber(x, y jsonv1.Number) (jsonv1.Number, bool) {
+	if x == "" {
+		return y, true
+	}
+	rx, _ := toRat(x)
+	ry, _ := toRat(y)
+	if rx == nil || ry == nil || rx.Sign() <= 0 || ry.Sign() <= 0 {
+		return "", false
+	}
+	// The least common multiple of p/q and r/s is lcm(p, r) / gcd(q, s).
+	var gcd, num, den big.Int
+	gcd.GCD(nil, nil, rx.Num(), ry.Num())
+	num.Mul(rx.Num(), ry.Num())
+	num.Quo(&num, &gcd)
+	den.GCD(nil, nil, rx.Denom(), ry.Denom())
+	lcm := new(big.Rat).SetFrac(&num, &den)
+	switch {
+	case lcm.Cmp(rx) == 0:
+		return x, true
+	case lcm.Cmp(ry) == 0:
+		return y, true
+	}
+	return jsonv1.Number(lcm.FloatString(decimals(lcm))), true
+}
+
+// decimals returns the number of decimals of a rational with a finite
+// decimal representation, as the ones of JSON numbers.
+func decimals(r *big.Rat) int {
+	x, ten := new(big.Rat).Set(r), big.NewRat(10, 1)
+	n := 0
+	for ; !x.IsInt() && n < 64; n++ {
+		x.Mul(x, ten)
+	}
+	return n
+}
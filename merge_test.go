@@ -0,0 +1,191 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, want string
+	}{
+		{
+			`{"type": "object", "properties": {"a": {"type": "string", "maxLength": 10}}, "required": ["a"]}`,
+			`{"properties": {"a": {"minLength": 1, "maxLength": 5}, "b": {"type": "integer"}}, "required": ["b", "a"]}`,
+			`{"type": "object", "properties": {"a": {"type": "string", "minLength": 1, "maxLength": 5}, "b": {"type": "integer"}}, "required": ["a", "b"]}`,
+		},
+		{
+			`{"properties": {"a": {}}, "additionalProperties": false}`,
+			`{"properties": {"c": {"type": "string"}}}`,
+			`{"properties": {"a": {}, "c": false}, "additionalProperties": false}`,
+		},
+		{
+			`{"properties": {"a": {"type": "string"}}}`,
+			`{"properties": {"a": {"type": "integer"}}}`,
+			`{"properties": {"a": false}}`,
+		},
+		{
+			`{"patternProperties": {"^x-": {"type": "string"}}}`,
+			`{"properties": {"x-a": {"maxLength": 3}}}`,
+			`{"patternProperties": {"^x-": {"type": "string"}}, "properties": {"x-a": {"type": "string", "maxLength": 3}}}`,
+		},
+		{`{"type": ["number", "null"]}`, `{"type": "integer"}`, `{"type": "integer"}`},
+		{`{"type": ["string", "null"]}`, `{"type": ["null", "boolean", "string"]}`, `{"type": ["string", "null"]}`},
+		{`{"enum": ["a", "b", 1]}`, `{"enum": [1.0, "b", "c"]}`, `{"enum": ["b", 1]}`},
+		{
+			`{"minimum": 1, "maximum": 10, "multipleOf": 2}`,
+			`{"minimum": 3, "exclusiveMaximum": 8, "multipleOf": 3}`,
+			`{"minimum": 3, "maximum": 10, "exclusiveMaximum": 8, "multipleOf": 6}`,
+		},
+		{`{"multipleOf": 0.5}`, `{"multipleOf": 0.75}`, `{"multipleOf": 1.5}`},
+		{`{"multipleOf": 2}`, `{"multipleOf": 4}`, `{"multipleOf": 4}`},
+		{`{"minimum": 5}`, `{"maximum": 4}`, `{"minimum": 5, "maximum": 4}`},
+		{`{"not": {"type": "null"}}`, `{"not": {"const": 0}}`, `{"not": {"anyOf": [{"type": "null"}, {"const": 0}]}}`},
+		{
+			`{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}, "minItems": 1}`,
+			`{"items": {"minimum": 0}, "minItems": 2, "uniqueItems": true}`,
+			`{"prefixItems": [{"type": "string", "minimum": 0}], "items": {"type": "integer", "minimum": 0}, "minItems": 2, "uniqueItems": true}`,
+		},
+		{`{"allOf": [{"minimum": 1}]}`, `{"allOf": [{"maximum": 2}]}`, `{"allOf": [{"minimum": 1}, {"maximum": 2}]}`},
+		{`{"$ref": "#/$defs/a", "title": "A"}`, `{"title": "B", "description": "b"}`, `{"$ref": "#/$defs/a", "title": "A", "description": "b"}`},
+		{`true`, `{"minimum": 1}`, `{"minimum": 1}`},
+		{`{"minimum": 1}`, `{}`, `{"minimum": 1}`},
+		{`{"enum": [1, "a", null]}`, `{"type": "string"}`, `{"type": "string", "enum": ["a"]}`},
+		{`{"type": ["integer", "null"]}`, `{"enum": [1.5, 2, null]}`, `{"type": ["integer", "null"], "enum": [2, null]}`},
+	} {
+		a, b := mustUnmarshalSchema(t, tc.a), mustUnmarshalSchema(t, tc.b)
+		m, err := Merge(a, b)
+		require.NoError(t, err, "%s and %s", tc.a, tc.b)
+		want := mustUnmarshalSchema(t, tc.want)
+		if !Equal(want, m) {
+			data, _ := m.MarshalJSON()
+			assert.Fail(t, "unexpected merge", "%s and %s: got %s", tc.a, tc.b, data)
+		}
+
+		// the schemas are not modified
+		assert.True(t, Equal(mustUnmarshalSchema(t, tc.a), a))
+		assert.True(t, Equal(mustUnmarshalSchema(t, tc.b), b))
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		a, b          string
+		unsatisfiable bool
+	}{
+		{`{"type": "string"}`, `{"type": "integer"}`, true},
+		{`{"properties": {"a": {"type": "string"}}, "required": ["a"]}`, `{"properties": {"a": {"type": "integer"}}}`, true},
+		{`{"enum": [1]}`, `{"enum": [2]}`, true},
+		{`{"const": 1}`, `{"enum": [2, 3]}`, true},
+		{`{"type": "integer", "minimum": 5}`, `{"maximum": 4}`, true},
+		{`{"type": "integer", "minimum": 5}`, `{"exclusiveMaximum": 5}`, true},
+		{`{"type": "string", "minLength": 5}`, `{"maxLength": 4}`, true},
+		{`{"type": "object", "required": ["a", "b"]}`, `{"maxProperties": 1}`, true},
+		{`{"type": "object", "properties": {"a": {}}, "additionalProperties": false}`, `{"required": ["b"]}`, true},
+		{`{"patternProperties": {"^x-": false}}`, `{"required": ["x-a"]}`, true},
+		{`{"required": ["a"]}`, `{"properties": {"a": false}}`, true},
+		{`false`, `{"minimum": 1}`, true},
+		{`{"type": "string"}`, `{"const": 1}`, true},
+		{`{"enum": [1, 2]}`, `{"type": "string"}`, true},
+		{`{"type": "number", "minimum": 5, "exclusiveMaximum": 5}`, `{}`, true},
+		{`true`, `{"type": "string", "minLength": 2, "maxLength": 1}`, true},
+		{`{"pattern": "^a"}`, `{"pattern": "^b"}`, false},
+		{`{"$ref": "#/$defs/a"}`, `{"$ref": "#/$defs/b"}`, false},
+		{`{"unevaluatedProperties": false}`, `{"properties": {"a": {}}}`, false},
+		{`{"additionalProperties": false}`, `{"patternProperties": {"^x-": {}}}`, false},
+		{`{"anyOf": [{"type": "string"}]}`, `{"anyOf": [{"type": "null"}]}`, false},
+		{`{"$id": "https://example.com/a"}`, `{"$id": "https://example.com/b"}`, false},
+	} {
+		_, err := Merge(mustUnmarshalSchema(t, tc.a), mustUnmarshalSchema(t, tc.b))
+		require.Error(t, err, "%s and %s", tc.a, tc.b)
+		assert.Equal(t, tc.unsatisfiable, errors.Is(err, ErrUnsatisfiable), "%s and %s: %v", tc.a, tc.b, err)
+	}
+}
+
+func TestFlattenAllOf(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$defs": {
+			"Named": {
+				"allOf": [
+					{"properties": {"name": {"type": "string"}}, "required": ["name"]},
+					{"properties": {"name": {"minLength": 1}}}
+				]
+			},
+			"Other": {"type": "object"}
+		},
+		"type": "object",
+		"allOf": [
+			{"properties": {"id": {"type": "integer"}}, "required": ["id"]},
+			{"allOf": [{"properties": {"tags": {"items": {"allOf": [{"type": "string"}, {"maxLength": 8}]}}}}]},
+			{"$ref": "#/$defs/Other"},
+			{"$ref": "#/$defs/Named"}
+		],
+		"unevaluatedProperties": false
+	}`)
+	s, err := FlattenAllOf(s)
+	require.NoError(t, err)
+	want := mustUnmarshalSchema(t, `{
+		"$defs": {
+			"Named": {"properties": {"name": {"type": "string", "minLength": 1}}, "required": ["name"]},
+			"Other": {"type": "object"}
+		},
+		"type": "object",
+		"$ref": "#/$defs/Other",
+		"properties": {
+			"id": {"type": "integer"},
+			"tags": {"items": {"type": "string", "maxLength": 8}}
+		},
+		"required": ["id"],
+		"allOf": [{"$ref": "#/$defs/Named"}],
+		"unevaluatedProperties": false
+	}`)
+	data, _ := s.MarshalJSON()
+	assert.True(t, Equal(want, s), string(data))
+
+	s, err = FlattenAllOf(mustUnmarshalSchema(t, `{"allOf": [true, {"allOf": [{}]}]}`))
+	require.NoError(t, err)
+	assert.True(t, s.isTrue())
+
+	// the entries designated by a reference keep their index
+	s, err = FlattenAllOf(mustUnmarshalSchema(t, `{
+		"allOf": [
+			{"required": ["a"]},
+			{"properties": {"a": {"type": "string"}}},
+			{"required": ["b"]}
+		],
+		"properties": {"c": {"$ref": "#/allOf/1/properties/a"}},
+		"$defs": {
+			"Nested": {
+				"$id": "https://example.com/nested",
+				"allOf": [{"minimum": 1}, {"maximum": 2}],
+				"properties": {"n": {"$ref": "#/allOf/0"}}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	want = mustUnmarshalSchema(t, `{
+		"allOf": [
+			{"required": ["a"]},
+			{"properties": {"a": {"type": "string"}}}
+		],
+		"required": ["b"],
+		"properties": {"c": {"$ref": "#/allOf/1/properties/a"}},
+		"$defs": {
+			"Nested": {
+				"$id": "https://example.com/nested",
+				"allOf": [{"minimum": 1}],
+				"maximum": 2,
+				"properties": {"n": {"$ref": "#/allOf/0"}}
+			}
+		}
+	}`)
+	data, _ = s.MarshalJSON()
+	assert.True(t, Equal(want, s), string(data))
+
+	_, err = FlattenAllOf(mustUnmarshalSchema(t, `{"properties": {"a": {"allOf": [{"type": "string"}, {"type": "integer"}]}}}`))
+	require.ErrorIs(t, err, ErrUnsatisfiable)
+	assert.Contains(t, err.Error(), `"/properties/a"`)
+}
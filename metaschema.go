@@ -0,0 +1,57 @@
+package jsonschema
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+// metaSchemaID is the URI of the 2020-12 meta-schema, which does not follow
+// the Version variable.
+const metaSchemaID = "https://json-schema.org/draft/2020-12/schema"
+
+//go:embed metaschema/draft2020-12
+var metaSchemaFS embed.FS
+
+// MetaSchemaLoader loads the official 2020-12 meta-schemas embedded in the
+// package: "https://json-schema.org/draft/2020-12/schema" and the vocabulary
+// meta-schemas under "https://json-schema.org/draft/2020-12/meta/". It is used
+// by DefaultRegistry.
+var MetaSchemaLoader = func() Loader {
+	fsys, err := fs.Sub(metaSchemaFS, "metaschema/draft2020-12")
+	if err != nil {
+		panic(err)
+	}
+	return FSLoader(fsys, "https://json-schema.org/draft/2020-12/")
+}()
+
+// metaSchemaValidator compiles the 2020-12 meta-schema once.
+var metaSchemaValidator = sync.OnceValues(func() (*Validator, error) {
+	s, err := MetaSchemaLoader.Load(metaSchemaID)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(s, WithLoader(MetaSchemaLoader))
+})
+
+// CheckMetaSchema validates the schema, as it would be marshalled, against
+// the official 2020-12 meta-schema. It catches the invalid schemas that can
+// be built with the Schema type, such as a negative "minLength" set through
+// Extras or an unknown type in TypeEnhanced. Failures are reported as a
+// *ValidationError.
+func (t *Schema) CheckMetaSchema() error {
+	v, err := metaSchemaValidator()
+	if err != nil {
+		return fmt.Errorf("jsonschema: cannot compile the meta-schema: %w", err)
+	}
+	data, err := t.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	inst, err := decodeInstance(data)
+	if err != nil {
+		return err
+	}
+	return v.Validate(inst)
+}
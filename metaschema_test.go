@@ -0,0 +1,58 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMetaSchemaFixtures(t *testing.T) {
+	files, err := filepath.Glob("fixtures/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		s := mustUnmarshalSchema(t, string(data))
+		assert.NoError(t, s.CheckMetaSchema(), file)
+	}
+}
+
+func TestCheckMetaSchema(t *testing.T) {
+	assert.NoError(t, TrueSchema.CheckMetaSchema())
+	assert.NoError(t, Reflect(&TestUser{}).CheckMetaSchema())
+
+	s := &Schema{Type: "string", Extras: map[string]any{"minLength": -1}}
+	err := s.CheckMetaSchema()
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.ErrorContains(t, err, "/minLength")
+
+	s = &Schema{TypeEnhanced: []string{"string", "strin"}}
+	assert.ErrorContains(t, s.CheckMetaSchema(), "/type")
+
+	s = &Schema{Properties: NewProperties()}
+	s.Properties.Set("id", &Schema{Anchor: "not an anchor"})
+	assert.ErrorContains(t, s.CheckMetaSchema(), "/properties/id/$anchor")
+}
+
+type invalidExtendedType struct {
+	Name string `json:"name"`
+}
+
+func (invalidExtendedType) JSONSchemaExtend(s *Schema) {
+	s.Extras = map[string]any{"maxItems": "ten"}
+}
+
+func TestReflectorCheckMetaSchema(t *testing.T) {
+	r := &Reflector{CheckMetaSchema: true}
+	assert.NotPanics(t, func() { r.Reflect(&TestUser{}) })
+
+	r = &Reflector{CheckMetaSchema: true, DoNotReference: true}
+	assert.PanicsWithError(t,
+		`jsonschema: invalid schema reflected from jsonschema.invalidExtendedType: jsonschema: /maxItems: expected integer but got string (at "/allOf/3/$ref/properties/maxItems/$ref/type")`,
+		func() { r.Reflect(&invalidExtendedType{}) })
+}
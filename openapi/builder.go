@@ -0,0 +1,239 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zchee/jsonschema"
+)
+
+// MediaTypeJSON is the media type of the request and response bodies.
+const MediaTypeJSON = "application/json"
+
+// definitionsPrefix and componentsPrefix are the prefixes of the references
+// to the schemas of the reflected "$defs", before and after being moved to
+// the components of the document.
+const (
+	definitionsPrefix = "#/$defs/"
+	componentsPrefix  = "#/components/schemas/"
+)
+
+// Builder assembles an OpenAPI 3.1 document from operations whose bodies are
+// described by Go types.
+type Builder struct {
+	reflector *jsonschema.Reflector
+	doc       *Document
+}
+
+// NewBuilder returns a Builder of a document with the info, reflecting the
+// bodies with r, or a default Reflector when nil. As the schemas of every
+// operation are shared through the components of the document, r should not
+// use DoNotReference nor a Dialect other than 2020-12.
+func NewBuilder(info Info, r *jsonschema.Reflector) *Builder {
+	if r == nil {
+		r = &jsonschema.Reflector{}
+	}
+	return &Builder{
+		reflector: r,
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+		},
+	}
+}
+
+// OperationOption describes the bodies of an operation added with
+// AddOperation.
+type OperationOption func(*operationBodies)
+
+type operationBodies struct {
+	request   any
+	responses []response
+}
+
+type response struct {
+	status      string
+	description string
+	body        any
+}
+
+// WithRequestBody sets the type of the required JSON request body, reflected
+// from the value v.
+func WithRequestBody(v any) OperationOption {
+	return func(o *operationBodies) {
+		o.request = v
+	}
+}
+
+// WithResponse adds the response for the HTTP status code, with the type of
+// its JSON body reflected from the value v, or without body when v is nil.
+// The status 0 stands for the "default" response.
+func WithResponse(status int, description string, v any) OperationOption {
+	return func(o *operationBodies) {
+		code := "default"
+		if status != 0 {
+			code = strconv.Itoa(status)
+		}
+		o.responses = append(o.responses, response{code, description, v})
+	}
+}
+
+// AddOperation adds the operation for the HTTP method and the path, such as
+// "/users/{id}", with the bodies described by the options. The schemas of
+// the bodies are reflected and their "$defs" moved to the components of the
+// document, the references to them being rewritten to
+// "#/components/schemas/{name}". Reflecting two different types under the
+// same name is an error, which leaves op and the document unmodified.
+func (b *Builder) AddOperation(method, path string, op *Operation, opts ...OperationOption) error {
+	item := b.doc.Paths[path]
+	if item == nil {
+		item = new(PathItem)
+	}
+	slot := item.operation(method)
+	if slot == nil {
+		return fmt.Errorf("openapi: unsupported method %q", method)
+	}
+	if *slot != nil {
+		return fmt.Errorf("openapi: duplicate operation %s %s", strings.ToUpper(method), path)
+	}
+
+	var bodies operationBodies
+	for _, opt := range opts {
+		opt(&bodies)
+	}
+	components := make(map[string]*jsonschema.Schema)
+	var requestBody *RequestBody
+	if bodies.request != nil {
+		s, err := b.reflect(bodies.request, components)
+		if err != nil {
+			return err
+		}
+		requestBody = &RequestBody{
+			Content:  map[string]*MediaType{MediaTypeJSON: {Schema: s}},
+			Required: true,
+		}
+	}
+	responses := maps.Clone(op.Responses)
+	for _, r := range bodies.responses {
+		if responses == nil {
+			responses = make(map[string]*Response)
+		}
+		resp := &Response{Description: r.description}
+		if r.body != nil {
+			s, err := b.reflect(r.body, components)
+			if err != nil {
+				return err
+			}
+			resp.Content = map[string]*MediaType{MediaTypeJSON: {Schema: s}}
+		}
+		responses[r.status] = resp
+	}
+
+	if len(components) > 0 {
+		if b.doc.Components == nil {
+			b.doc.Components = &Components{Schemas: make(map[string]*jsonschema.Schema)}
+		}
+		maps.Copy(b.doc.Components.Schemas, components)
+	}
+	if requestBody != nil {
+		op.RequestBody = requestBody
+	}
+	op.Responses = responses
+	*slot = op
+	b.doc.Paths[path] = item
+	return nil
+}
+
+// Document returns the document built so far.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// operation returns the field of the path item for the HTTP method, or nil
+// for an unknown method.
+func (p *PathItem) operation(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	}
+	return nil
+}
+
+// reflect returns the schema of the value, adding its definitions that are
+// not yet components of the document to components.
+func (b *Builder) reflect(v any, components map[string]*jsonschema.Schema) (*jsonschema.Schema, error) {
+	s := b.reflector.Reflect(v)
+	defs := s.Definitions
+	s.Version, s.ID, s.Definitions = "", jsonschema.EmptyID, nil
+	rewriteRefs(s)
+
+	var schemas map[string]*jsonschema.Schema
+	if b.doc.Components != nil {
+		schemas = b.doc.Components.Schemas
+	}
+	for _, name := range slices.Sorted(maps.Keys(defs)) {
+		rewriteRefs(defs[name])
+		existing, ok := schemas[name]
+		if !ok {
+			existing, ok = components[name]
+		}
+		if !ok {
+			components[name] = defs[name]
+			continue
+		}
+		same, err := equalJSON(existing, defs[name])
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			return nil, fmt.Errorf("openapi: conflicting schemas for component %q", name)
+		}
+	}
+	return s, nil
+}
+
+func equalJSON(a, b *jsonschema.Schema) (bool, error) {
+	x, err := a.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+	y, err := b.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(x, y), nil
+}
+
+// rewriteRefs rewrites the references to "$defs" of the schema and of its
+// sub-schemas as references to the components of the document.
+func rewriteRefs(s *jsonschema.Schema) {
+	_ = jsonschema.Walk(s, func(_ jsonschema.Pointer, s *jsonschema.Schema) error {
+		if rest, ok := strings.CutPrefix(s.Ref, definitionsPrefix); ok {
+			s.Ref = componentsPrefix + rest
+		}
+		if rest, ok := strings.CutPrefix(s.DynamicRef, definitionsPrefix); ok {
+			s.DynamicRef = componentsPrefix + rest
+		}
+		return nil
+	})
+}
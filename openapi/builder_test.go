@@ -0,0 +1,101 @@
+package openapi
+
+import (
+	"encoding/json/jsontext"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zchee/jsonschema"
+)
+
+var updateFixtures = flag.Bool("update", false, "set to update fixtures")
+
+type Pet struct {
+	ID    int    `json:"id" jsonschema:"required"`
+	Name  string `json:"name" jsonschema:"required,minLength=1"`
+	Owner *Owner `json:"owner,omitempty"`
+}
+
+type Owner struct {
+	Name string `json:"name"`
+}
+
+type NewPet struct {
+	Name  string `json:"name" jsonschema:"required,minLength=1"`
+	Owner *Owner `json:"owner,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(Info{Title: "Pet Store", Version: "1.0.0"}, &jsonschema.Reflector{Anonymous: true})
+
+	require.NoError(t, b.AddOperation(http.MethodGet, "/pets", &Operation{OperationID: "listPets", Tags: []string{"pets"}},
+		WithResponse(http.StatusOK, "The pets.", []Pet{}),
+		WithResponse(0, "Unexpected error.", Error{}),
+	))
+	require.NoError(t, b.AddOperation(http.MethodPost, "/pets", &Operation{OperationID: "createPet", Tags: []string{"pets"}},
+		WithRequestBody(NewPet{}),
+		WithResponse(http.StatusCreated, "The created pet.", Pet{}),
+		WithResponse(0, "Unexpected error.", Error{}),
+	))
+	require.NoError(t, b.AddOperation("delete", "/pets/{id}", &Operation{
+		OperationID: "deletePet",
+		Parameters:  []*Parameter{{Name: "id", In: "path", Required: true, Schema: &jsonschema.Schema{Type: "integer"}}},
+	}, WithResponse(http.StatusNoContent, "Deleted.", nil)))
+
+	data, err := b.Document().MarshalJSON()
+	require.NoError(t, err)
+	v := jsontext.Value(data)
+	require.NoError(t, v.Indent(jsontext.WithIndent("  ")))
+	compareFixture(t, "fixtures/petstore.json", v)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	b := NewBuilder(Info{Title: "Errors", Version: "1.0.0"}, nil)
+	require.NoError(t, b.AddOperation(http.MethodGet, "/pets", &Operation{}, WithResponse(http.StatusOK, "Pets.", []Pet{})))
+
+	err := b.AddOperation(http.MethodGet, "/pets", &Operation{})
+	assert.EqualError(t, err, "openapi: duplicate operation GET /pets")
+
+	err = b.AddOperation("fetch", "/pets", &Operation{})
+	assert.EqualError(t, err, `openapi: unsupported method "fetch"`)
+
+	type Pet struct {
+		Species string `json:"species"`
+	}
+	err = b.AddOperation(http.MethodPost, "/pets", &Operation{}, WithRequestBody(Pet{}))
+	assert.EqualError(t, err, `openapi: conflicting schemas for component "Pet"`)
+
+	// a failed operation leaves neither the operation nor the document modified
+	type Adopter struct {
+		Name string `json:"name"`
+	}
+	op := &Operation{OperationID: "adopt"}
+	err = b.AddOperation(http.MethodPost, "/owners", op, WithRequestBody(Adopter{}), WithResponse(http.StatusCreated, "Adopted.", Pet{}))
+	assert.EqualError(t, err, `openapi: conflicting schemas for component "Pet"`)
+	assert.Equal(t, &Operation{OperationID: "adopt"}, op)
+	assert.NotContains(t, b.Document().Components.Schemas, "Adopter")
+	assert.NotContains(t, b.Document().Paths, "/owners")
+}
+
+func compareFixture(t *testing.T, f string, actual []byte) {
+	t.Helper()
+	if *updateFixtures {
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0o755))
+		require.NoError(t, os.WriteFile(f, actual, 0o600))
+	}
+	expected, err := os.ReadFile(f)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+	assert.Equal(t, string(expected), string(actual), "keys are expected in the same order")
+}
@@ -0,0 +1,100 @@
+// Package openapi builds OpenAPI 3.1 documents from Go types, reflecting the
+// request and response bodies of every operation with a jsonschema.Reflector
+// into one shared set of component schemas.
+package openapi
+
+import (
+	json "encoding/json/v2"
+
+	"github.com/zchee/jsonschema"
+)
+
+// Version is the version of the OpenAPI Specification of the documents.
+const Version = "3.1.0"
+
+// Document is the root object of an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths,omitempty"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// MarshalJSON marshals the document with the keys of maps, such as paths and
+// component names, in sorted order, so that the output is stable.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	type document Document
+	return json.Marshal((*document)(d), json.Deterministic(true))
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a server hosting the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations available on a path.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation describes an API operation on a path.
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitzero"`
+}
+
+// Parameter describes a parameter of an operation, located by In: "query",
+// "header", "path" or "cookie".
+type Parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitzero"`
+	Deprecated  bool               `json:"deprecated,omitzero"`
+	Schema      *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+	Required    bool                  `json:"required,omitzero"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body for a media type.
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+// Components holds the reusable schemas of the document.
+type Components struct {
+	Schemas map[string]*jsonschema.Schema `json:"schemas,omitempty"`
+}
@@ -0,0 +1,257 @@
+package jsonschema
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// openAPI30Unsupported are the keywords that the OpenAPI 3.0 Schema Object
+// does not have, and which are removed by ConvertToOpenAPI30.
+var openAPI30Unsupported = []string{
+	"$vocabulary", "$anchor", "$dynamicAnchor", "$dynamicRef",
+	"if", "then", "else", "dependentSchemas", "dependentRequired",
+	"prefixItems", "contains", "minContains", "maxContains",
+	"patternProperties", "propertyNames",
+	"unevaluatedItems", "unevaluatedProperties",
+	"contentEncoding", "contentMediaType", "contentSchema",
+}
+
+// ConvertToOpenAPI30 returns a copy of the schema rewritten as an OpenAPI 3.0
+// Schema Object, for services that do not publish OpenAPI 3.1 yet. The
+// schemas of "$defs", at any depth, are returned separately, to be placed
+// under "components/schemas" of the OpenAPI document, and the "$ref" values
+// designating them are rewritten to "#/components/schemas/{name}". Besides:
+//
+//   - a "null" entry of "oneOf" or "anyOf", as produced by the nullable tag,
+//     and the "null" type become "nullable: true";
+//   - a list of types becomes an "anyOf" of types;
+//   - "const" becomes a single value "enum", numeric "exclusiveMinimum" and
+//     "exclusiveMaximum" the boolean form, and "examples" an "example";
+//   - "$ref" with other keywords is moved into "allOf", as those keywords
+//     would be ignored;
+//   - boolean schemas become their object equivalent, except for
+//     "additionalProperties";
+//   - "$schema", "$id" and "$comment" are removed.
+//
+// Keywords that OpenAPI 3.0 does not have, such as "prefixItems" or "if", are
+// removed and reported as warnings, and so are the references that do not
+// designate a schema of "$defs". The rewritten keywords are held by Extras,
+// so the results should only be marshalled.
+func (t *Schema) ConvertToOpenAPI30() (*Schema, Definitions, []Warning, error) {
+	if t == nil {
+		return nil, nil, nil, errors.New("jsonschema: cannot convert a nil schema")
+	}
+	c := &openAPIConverter{
+		converter:  converter{dialect: Draft04, root: t},
+		names:      make(map[string]string),
+		components: make(Definitions),
+	}
+	c.collectDefinitions(t, "")
+	s := c.convert(t, "")
+	return s, c.components, c.warnings, nil
+}
+
+type openAPIConverter struct {
+	converter
+	// names holds the component name of every schema of "$defs", by its
+	// JSON Pointer in the root.
+	names      map[string]string
+	components Definitions
+}
+
+// collectDefinitions names the component of every schema of "$defs", keeping
+// the names of the root "$defs" and adding a suffix to the others when
+// already taken.
+func (c *openAPIConverter) collectDefinitions(s *Schema, ptr string) {
+	if s == nil || s.boolean != nil {
+		return
+	}
+	for _, name := range sortedKeys(s.Definitions) {
+		unique := uniqueDefName(c.components, name)
+		c.names[ptr+"/$defs/"+escapePointerToken(name)] = unique
+		c.components[unique] = nil
+	}
+	for p, sub := range s.subschemas() {
+		c.collectDefinitions(sub, ptr+p)
+	}
+}
+
+// convert returns the copy of the schema at the pointer rewritten for
+// OpenAPI 3.0.
+func (c *openAPIConverter) convert(s *Schema, ptr string) *Schema {
+	if s.boolean != nil {
+		if *s.boolean {
+			return emptySchemaObject()
+		}
+		return &Schema{Not: emptySchemaObject()}
+	}
+
+	n := s.shallowCopy()
+	n.Extras = maps.Clone(s.Extras)
+	n.Version, n.ID, n.Comments = "", EmptyID, ""
+	for _, name := range openAPI30Unsupported {
+		if n.clearKeyword(name) {
+			c.warn(ptr, name, "not supported by OpenAPI 3.0, dropped")
+		}
+	}
+	var nullOne, nullAny bool
+	n.OneOf, nullOne = withoutNullSchemas(n.OneOf)
+	n.AnyOf, nullAny = withoutNullSchemas(n.AnyOf)
+	nullable := nullOne || nullAny
+
+	for _, name := range sortedKeys(n.Definitions) {
+		p := ptr + "/$defs/" + escapePointerToken(name)
+		c.components[c.names[p]] = c.convert(n.Definitions[name], p)
+	}
+	n.Definitions = nil
+	type child struct {
+		pointer string
+		schema  *Schema
+	}
+	var children []child
+	for p, sub := range n.subschemas() {
+		children = append(children, child{p, sub})
+	}
+	for _, ch := range children {
+		if ch.pointer == "/additionalProperties" && ch.schema.boolean != nil {
+			continue
+		}
+		_ = n.Set(ch.pointer, c.convert(ch.schema, ptr+ch.pointer))
+	}
+
+	types := n.TypeEnhanced
+	if n.Type != "" {
+		types = []string{n.Type}
+	}
+	if slices.Contains(types, "null") {
+		nullable = true
+		types = slices.DeleteFunc(slices.Clone(types), func(t string) bool { return t == "null" })
+		if len(types) == 0 {
+			c.warn(ptr, "type", "null cannot be expressed in OpenAPI 3.0, replaced by nullable")
+			n.Enum = []any{nil}
+		}
+	}
+	n.Type, n.TypeEnhanced = "", nil
+	switch len(types) {
+	case 0:
+	case 1:
+		n.Type = types[0]
+	default:
+		anyOf := make([]*Schema, len(types))
+		for i, typ := range types {
+			anyOf[i] = &Schema{Type: typ}
+		}
+		if n.AnyOf == nil {
+			n.AnyOf = anyOf
+		} else {
+			n.AllOf = append(n.AllOf, &Schema{AnyOf: anyOf})
+		}
+	}
+
+	c.convertDraft04(n)
+	if len(n.Examples) > 0 {
+		if len(n.Examples) > 1 {
+			c.warn(ptr, "examples", "only the first example is kept by OpenAPI 3.0")
+		}
+		n.setExtraValue("example", n.Examples[0])
+		n.Examples = nil
+	}
+
+	if n.Ref != "" {
+		n.Ref = c.convertRef(n.Ref, ptr)
+	}
+	for _, list := range []*[]*Schema{&n.OneOf, &n.AnyOf} {
+		if !nullable || len(*list) != 1 {
+			continue
+		}
+		// the oneOf of a nullable field, or an anyOf alike
+		only := (*list)[0]
+		*list = nil
+		if empty, _ := isEmptySchema(n); empty && only.Ref == "" {
+			n = only.shallowCopy()
+			n.Extras = maps.Clone(only.Extras)
+		} else {
+			n.AllOf = append(n.AllOf, only)
+		}
+		break
+	}
+	if nullable {
+		n.setExtraValue("nullable", true)
+	}
+	if n.Ref != "" {
+		ref := n.Ref
+		n.Ref = ""
+		if empty, _ := isEmptySchema(n); empty {
+			n.Ref = ref
+		} else {
+			n.AllOf = append(n.AllOf, &Schema{Ref: ref})
+		}
+	}
+	if empty, _ := isEmptySchema(n); empty {
+		return emptySchemaObject()
+	}
+	return n
+}
+
+// withoutNullSchemas returns the list without its {"type": "null"} schemas,
+// reporting whether it had some.
+func withoutNullSchemas(list []*Schema) ([]*Schema, bool) {
+	if list == nil {
+		return nil, false
+	}
+	out := slices.DeleteFunc(slices.Clone(list), func(s *Schema) bool {
+		if s == nil || s.boolean != nil || s.Type != "null" {
+			return false
+		}
+		members, err := schemaMembers(s)
+		return err == nil && len(members) == 1
+	})
+	return out, len(out) < len(list)
+}
+
+// convertRef rewrites a reference to a schema of "$defs" as a reference to
+// its component.
+func (c *openAPIConverter) convertRef(ref, ptr string) string {
+	uri, fragment, _ := strings.Cut(ref, "#")
+	if uri != "" && uri != c.root.ID.String() {
+		return ref // another document
+	}
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		fragment = ""
+	}
+	def := ""
+	for p := range c.names {
+		if (fragment == p || strings.HasPrefix(fragment, p+"/")) && len(p) > len(def) {
+			def = p
+		}
+	}
+	if def == "" {
+		c.warn(ptr, "$ref", "%q does not designate a schema of $defs, kept as is", ref)
+		return ref
+	}
+	pointer := "/components/schemas/" + escapePointerToken(c.names[def]) + fragment[len(def):]
+	return "#" + (&url.URL{Fragment: pointer}).EscapedFragment()
+}
+
+// clearKeyword removes the keyword from the schema, reporting whether it was
+// set.
+func (t *Schema) clearKeyword(name string) bool {
+	v := reflect.ValueOf(t).Elem()
+	st := v.Type()
+	for i := range st.NumField() {
+		tag, _, _ := strings.Cut(st.Field(i).Tag.Get("json"), ",")
+		if tag != name {
+			continue
+		}
+		f := v.Field(i)
+		set := !f.IsZero()
+		f.SetZero()
+		return set
+	}
+	return false
+}
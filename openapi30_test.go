@@ -0,0 +1,98 @@
+package jsonschema
+
+import (
+	"encoding/json/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertToOpenAPI30(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/order",
+		"$ref": "#/$defs/Order",
+		"$defs": {
+			"Order": {
+				"type": "object",
+				"properties": {
+					"id": {"type": ["string", "integer"]},
+					"kind": {"const": "order"},
+					"total": {"type": "number", "exclusiveMinimum": 0, "examples": [10, 20]},
+					"note": {"oneOf": [{"type": "string", "maxLength": 100}, {"type": "null"}]},
+					"customer": {"oneOf": [{"$ref": "#/$defs/Customer"}, {"type": "null"}]},
+					"lines": {"type": ["array", "null"], "items": {"$ref": "https://example.com/order#/$defs/Line"}},
+					"pair": {"prefixItems": [true, false]},
+					"self": {"$ref": "#"}
+				},
+				"additionalProperties": false
+			},
+			"Customer": {
+				"$comment": "from the CRM",
+				"type": "object",
+				"properties": {"name": {"type": "string"}},
+				"$defs": {"Line": {"type": "string"}}
+			},
+			"Line": {"type": "object", "additionalProperties": true, "not": false}
+		}
+	}`)
+
+	out, components, warnings, err := s.ConvertToOpenAPI30()
+	require.NoError(t, err)
+	data, err := out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"$ref": "#/components/schemas/Order"}`, string(data))
+
+	data, err = json.Marshal(components, json.Deterministic(true))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Order": {
+			"type": "object",
+			"properties": {
+				"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+				"kind": {"enum": ["order"]},
+				"total": {"type": "number", "minimum": 0, "exclusiveMinimum": true, "example": 10},
+				"note": {"type": "string", "maxLength": 100, "nullable": true},
+				"customer": {"allOf": [{"$ref": "#/components/schemas/Customer"}], "nullable": true},
+				"lines": {"type": "array", "items": {"$ref": "#/components/schemas/Line"}, "nullable": true},
+				"pair": {},
+				"self": {"$ref": "#"}
+			},
+			"additionalProperties": false
+		},
+		"Customer": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}}
+		},
+		"Line": {"type": "object", "additionalProperties": true, "not": {"not": {}}},
+		"Line-2": {"type": "string"}
+	}`, string(data))
+
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	assert.Equal(t, []string{
+		"/$defs/Order/properties/total/examples: only the first example is kept by OpenAPI 3.0",
+		"/$defs/Order/properties/pair/prefixItems: not supported by OpenAPI 3.0, dropped",
+		`/$defs/Order/properties/self/$ref: "#" does not designate a schema of $defs, kept as is`,
+	}, messages)
+}
+
+func TestConvertToOpenAPI30Nullable(t *testing.T) {
+	s := (&Reflector{}).Reflect(&TestNullable{})
+
+	_, components, warnings, err := s.ConvertToOpenAPI30()
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Contains(t, components, "TestNullable")
+	data, err := components["TestNullable"].MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {"child1": {"type": "string", "nullable": true}},
+		"additionalProperties": false,
+		"required": ["child1"]
+	}`, string(data))
+}
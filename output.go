@@ -0,0 +1,176 @@
+package jsonschema
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// OutputFormat selects the structure of the result returned by
+// Validator.Evaluate.
+// RFC draft-bhutton-json-schema-00 section 12.4
+type OutputFormat int
+
+const (
+	// FlagOutput only reports whether the instance is valid.
+	FlagOutput OutputFormat = iota // section 12.4.1
+	// BasicOutput reports a flat list of errors, or of annotations when the
+	// instance is valid.
+	BasicOutput // section 12.4.2
+	// DetailedOutput reports a condensed hierarchy of errors or annotations
+	// following the structure of the schema.
+	DetailedOutput // section 12.4.3
+	// VerboseOutput reports the full hierarchy of every evaluated schema,
+	// including the ones that passed.
+	VerboseOutput // section 12.4.4
+)
+
+// String returns the name used for the format in the specification.
+func (f OutputFormat) String() string {
+	switch f {
+	case FlagOutput:
+		return "flag"
+	case BasicOutput:
+		return "basic"
+	case DetailedOutput:
+		return "detailed"
+	case VerboseOutput:
+		return "verbose"
+	}
+	return fmt.Sprintf("OutputFormat(%d)", int(f))
+}
+
+// OutputUnit is a single unit of validation output. Units are nested to
+// describe the evaluation of sub-schemas; how much of that hierarchy is kept
+// depends on the OutputFormat.
+// RFC draft-bhutton-json-schema-00 section 12.3
+type OutputUnit struct {
+	Valid                   bool          `json:"valid"`
+	KeywordLocation         string        `json:"keywordLocation"`                   // section 12.3.1
+	AbsoluteKeywordLocation string        `json:"absoluteKeywordLocation,omitempty"` // section 12.3.2
+	InstanceLocation        string        `json:"instanceLocation"`                  // section 12.3.3
+	Error                   string        `json:"error,omitempty"`                   // section 12.3.4
+	Annotation              any           `json:"annotation,omitempty"`              // section 12.3.4
+	Errors                  []*OutputUnit `json:"errors,omitempty"`                  // section 12.3.5
+	Annotations             []*OutputUnit `json:"annotations,omitempty"`             // section 12.3.5
+
+	// flag is set on the result of the flag format, which only contains the
+	// "valid" property.
+	flag bool
+}
+
+// MarshalJSON serializes the unit following the standard output structure.
+func (u *OutputUnit) MarshalJSON() ([]byte, error) {
+	if u.flag {
+		if u.Valid {
+			return []byte(`{"valid":true}`), nil
+		}
+		return []byte(`{"valid":false}`), nil
+	}
+	type outputUnitAlt OutputUnit
+	return jsonv1.Marshal((*outputUnitAlt)(u))
+}
+
+// Evaluate validates the instance and reports the result in the requested
+// output format. Unlike Validate, the result also describes the annotations
+// collected from a valid instance. An error is only returned when the instance
+// cannot be encoded as JSON.
+func (v *Validator) Evaluate(instance any, format OutputFormat) (*OutputUnit, error) {
+	inst, err := normalizeInstance(instance)
+	if err != nil {
+		return nil, err
+	}
+	st := &evalState{
+		annotate: format != FlagOutput,
+		verbose:  format == VerboseOutput,
+		track:    v.track,
+		dynamic:  v.dynamic,
+	}
+	u := st.eval(v.root, inst, "", "")
+	if u == nil {
+		u = &OutputUnit{Valid: true, AbsoluteKeywordLocation: v.root.keywordURI("")}
+	}
+
+	switch format {
+	case FlagOutput:
+		return &OutputUnit{Valid: u.Valid, flag: true}, nil
+	case BasicOutput:
+		root := &OutputUnit{
+			Valid:                   u.Valid,
+			AbsoluteKeywordLocation: u.AbsoluteKeywordLocation,
+		}
+		if u.Valid {
+			root.Annotations = u.flatten(nil, func(c *OutputUnit) bool { return c.Annotation != nil })
+		} else {
+			root.Errors = u.flatten(nil, func(c *OutputUnit) bool { return c.Error != "" })
+		}
+		return root, nil
+	case DetailedOutput:
+		return u.condense(), nil
+	}
+	return u, nil
+}
+
+// flatten collects the nested units matching the filter in depth-first order.
+func (u *OutputUnit) flatten(dst []*OutputUnit, keep func(*OutputUnit) bool) []*OutputUnit {
+	for _, c := range u.children() {
+		if keep(c) {
+			leaf := *c
+			leaf.Errors, leaf.Annotations = nil, nil
+			dst = append(dst, &leaf)
+		}
+		dst = c.flatten(dst, keep)
+	}
+	return dst
+}
+
+// condense removes the intermediate units that only wrap a single other unit
+// without adding any information of their own, as expected by the detailed
+// output format.
+func (u *OutputUnit) condense() *OutputUnit {
+	out := *u
+	children := u.children()
+	condensed := make([]*OutputUnit, 0, len(children))
+	for _, c := range children {
+		c = c.condense()
+		for c.Error == "" && c.Annotation == nil && len(c.children()) == 1 {
+			c = c.children()[0]
+		}
+		if c.Error == "" && c.Annotation == nil && len(c.children()) == 0 {
+			continue
+		}
+		condensed = append(condensed, c)
+	}
+	if len(condensed) == 0 {
+		condensed = nil
+	}
+	if u.Valid {
+		out.Annotations = condensed
+	} else {
+		out.Errors = condensed
+	}
+	return &out
+}
+
+func (u *OutputUnit) children() []*OutputUnit {
+	if u.Valid {
+		return u.Annotations
+	}
+	return u.Errors
+}
+
+// keywordURI builds the absolute keyword location of a keyword of the node,
+// or of the node itself when the keyword is empty.
+func (n *schemaNode) keywordURI(keyword string) string {
+	if n.base == "" {
+		return ""
+	}
+	ptr := n.ptr
+	if keyword != "" {
+		ptr += "/" + keyword
+	}
+	u := url.URL{Fragment: ptr}
+	return n.base + "#" + strings.TrimPrefix(u.String(), "#")
+}
@@ -0,0 +1,168 @@
+package jsonschema
+
+import (
+	"testing"
+
+	jsonv1 "github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const outputTestSchema = `{
+	"$id": "https://example.com/polygon",
+	"$defs": {
+		"point": {
+			"type": "object",
+			"properties": {
+				"x": {"type": "number"},
+				"y": {"type": "number"}
+			},
+			"additionalProperties": false,
+			"required": ["x", "y"]
+		}
+	},
+	"type": "array",
+	"items": {"$ref": "#/$defs/point"},
+	"minItems": 3
+}`
+
+func evaluateJSON(t *testing.T, schema, instance string, format OutputFormat) string {
+	t.Helper()
+	v, err := Compile(mustUnmarshalSchema(t, schema))
+	require.NoError(t, err)
+	out, err := v.Evaluate(mustDecodeInstance(t, instance), format)
+	require.NoError(t, err)
+	data, err := jsonv1.Marshal(out)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestEvaluateFlag(t *testing.T) {
+	assert.JSONEq(t, `{"valid":false}`, evaluateJSON(t, outputTestSchema, `[{"x":1,"y":2}]`, FlagOutput))
+	assert.JSONEq(t, `{"valid":true}`, evaluateJSON(t, outputTestSchema, `[{"x":1,"y":2},{"x":1,"y":2},{"x":1,"y":2}]`, FlagOutput))
+}
+
+func TestEvaluateBasic(t *testing.T) {
+	out := evaluateJSON(t, outputTestSchema, `[{"x":2.5,"y":1.3},{"x":1,"z":6.7}]`, BasicOutput)
+	assert.JSONEq(t, `{
+		"valid": false,
+		"keywordLocation": "",
+		"absoluteKeywordLocation": "https://example.com/polygon#",
+		"instanceLocation": "",
+		"errors": [
+			{
+				"valid": false,
+				"keywordLocation": "/minItems",
+				"absoluteKeywordLocation": "https://example.com/polygon#/minItems",
+				"instanceLocation": "",
+				"error": "array has 2 items, fewer than minItems 3"
+			},
+			{
+				"valid": false,
+				"keywordLocation": "/items/$ref/required",
+				"absoluteKeywordLocation": "https://example.com/polygon#/$defs/point/required",
+				"instanceLocation": "/1",
+				"error": "missing required properties [\"y\"]"
+			},
+			{
+				"valid": false,
+				"keywordLocation": "/items/$ref/additionalProperties",
+				"absoluteKeywordLocation": "https://example.com/polygon#/$defs/point/additionalProperties",
+				"instanceLocation": "/1/z",
+				"error": "false schema does not allow any value"
+			}
+		]
+	}`, out)
+}
+
+func TestEvaluateDetailed(t *testing.T) {
+	out := evaluateJSON(t, outputTestSchema, `[{"x":2.5,"y":1.3},{"x":1,"z":6.7}]`, DetailedOutput)
+	assert.JSONEq(t, `{
+		"valid": false,
+		"keywordLocation": "",
+		"absoluteKeywordLocation": "https://example.com/polygon#",
+		"instanceLocation": "",
+		"errors": [
+			{
+				"valid": false,
+				"keywordLocation": "/minItems",
+				"absoluteKeywordLocation": "https://example.com/polygon#/minItems",
+				"instanceLocation": "",
+				"error": "array has 2 items, fewer than minItems 3"
+			},
+			{
+				"valid": false,
+				"keywordLocation": "/items/$ref",
+				"absoluteKeywordLocation": "https://example.com/polygon#/$defs/point",
+				"instanceLocation": "/1",
+				"errors": [
+					{
+						"valid": false,
+						"keywordLocation": "/items/$ref/required",
+						"absoluteKeywordLocation": "https://example.com/polygon#/$defs/point/required",
+						"instanceLocation": "/1",
+						"error": "missing required properties [\"y\"]"
+					},
+					{
+						"valid": false,
+						"keywordLocation": "/items/$ref/additionalProperties",
+						"absoluteKeywordLocation": "https://example.com/polygon#/$defs/point/additionalProperties",
+						"instanceLocation": "/1/z",
+						"error": "false schema does not allow any value"
+					}
+				]
+			}
+		]
+	}`, out)
+}
+
+func TestEvaluateAnnotations(t *testing.T) {
+	schema := `{
+		"title": "root",
+		"properties": {"a": {"description": "first"}, "b": {"type": "string"}},
+		"not": {"type": "string", "title": "discarded"}
+	}`
+
+	basic := evaluateJSON(t, schema, `{"a":1}`, BasicOutput)
+	assert.JSONEq(t, `{
+		"valid": true,
+		"keywordLocation": "",
+		"instanceLocation": "",
+		"annotations": [
+			{"valid": true, "keywordLocation": "/properties/a/description", "instanceLocation": "/a", "annotation": "first"},
+			{"valid": true, "keywordLocation": "/properties", "instanceLocation": "", "annotation": ["a"]},
+			{"valid": true, "keywordLocation": "/title", "instanceLocation": "", "annotation": "root"}
+		]
+	}`, basic)
+
+	verbose := evaluateJSON(t, `{"properties":{"a":true,"b":{"type":"integer"}}}`, `{"a":1,"b":2}`, VerboseOutput)
+	assert.JSONEq(t, `{
+		"valid": true,
+		"keywordLocation": "",
+		"instanceLocation": "",
+		"annotations": [
+			{"valid": true, "keywordLocation": "/properties/a", "instanceLocation": "/a"},
+			{"valid": true, "keywordLocation": "/properties/b", "instanceLocation": "/b"},
+			{"valid": true, "keywordLocation": "/properties", "instanceLocation": "", "annotation": ["a", "b"]}
+		]
+	}`, verbose)
+}
+
+func TestValidationErrorAbsoluteLocation(t *testing.T) {
+	v, err := Compile(mustUnmarshalSchema(t, outputTestSchema))
+	require.NoError(t, err)
+	err = v.Validate(mustDecodeInstance(t, `[{"x":"a","y":1},{"x":1,"y":1},{"x":1,"y":1}]`))
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	leaves := verr.leaves(nil)
+	require.Len(t, leaves, 1)
+	assert.Equal(t, "/items/$ref/properties/x/type", leaves[0].KeywordLocation)
+	assert.Equal(t, "https://example.com/polygon#/$defs/point/properties/x/type", leaves[0].AbsoluteKeywordLocation)
+	assert.Equal(t, "/0/x", leaves[0].InstanceLocation)
+}
+
+func TestOutputFormatString(t *testing.T) {
+	assert.Equal(t, "flag", FlagOutput.String())
+	assert.Equal(t, "verbose", VerboseOutput.String())
+	assert.Equal(t, "OutputFormat(9)", OutputFormat(9).String())
+}
@@ -0,0 +1,318 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// subschemas iterates over the direct sub-schemas of the schema, yielding the
+// JSON Pointer of each one relative to the schema, such as "/properties/name".
+// Sub-schemas are visited in a stable order.
+func (t *Schema) subschemas() iter.Seq2[string, *Schema] {
+	return func(yield func(string, *Schema) bool) {
+		if t == nil || t.boolean != nil {
+			return
+		}
+		for _, name := range sortedKeys(t.Definitions) {
+			if !yield("/$defs/"+escapePointerToken(name), t.Definitions[name]) {
+				return
+			}
+		}
+		for _, l := range []struct {
+			keyword string
+			list    []*Schema
+		}{
+			{"allOf", t.AllOf},
+			{"anyOf", t.AnyOf},
+			{"oneOf", t.OneOf},
+			{"prefixItems", t.PrefixItems},
+		} {
+			for i, s := range l.list {
+				if !yield("/"+l.keyword+"/"+strconv.Itoa(i), s) {
+					return
+				}
+			}
+		}
+		for _, kw := range singleKeywords {
+			if s := *kw.field(t); s != nil {
+				if !yield("/"+kw.name, s) {
+					return
+				}
+			}
+		}
+		for _, m := range []struct {
+			keyword string
+			values  map[string]*Schema
+		}{
+			{"dependentSchemas", t.DependentSchemas},
+			{"patternProperties", t.PatternProperties},
+		} {
+			for _, name := range sortedKeys(m.values) {
+				if !yield("/"+m.keyword+"/"+escapePointerToken(name), m.values[name]) {
+					return
+				}
+			}
+		}
+		if t.Properties != nil {
+			for _, name := range t.Properties.order {
+				if !yield("/properties/"+escapePointerToken(name), t.Properties.values[name]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// singleKeywords are the keywords taking a single sub-schema.
+var singleKeywords = []struct {
+	name  string
+	field func(*Schema) **Schema
+}{
+	{"not", func(s *Schema) **Schema { return &s.Not }},
+	{"if", func(s *Schema) **Schema { return &s.If }},
+	{"then", func(s *Schema) **Schema { return &s.Then }},
+	{"else", func(s *Schema) **Schema { return &s.Else }},
+	{"items", func(s *Schema) **Schema { return &s.Items }},
+	{"contains", func(s *Schema) **Schema { return &s.Contains }},
+	{"additionalProperties", func(s *Schema) **Schema { return &s.AdditionalProperties }},
+	{"propertyNames", func(s *Schema) **Schema { return &s.PropertyNames }},
+	{"unevaluatedItems", func(s *Schema) **Schema { return &s.UnevaluatedItems }},
+	{"unevaluatedProperties", func(s *Schema) **Schema { return &s.UnevaluatedProperties }},
+	{"contentSchema", func(s *Schema) **Schema { return &s.ContentSchema }},
+}
+
+// At returns the sub-schema designated by a JSON Pointer relative to the
+// schema, such as "/$defs/User/properties/name" or "/allOf/0". Every keyword
+// holding sub-schemas can be traversed, and "~1" and "~0" in the tokens stand
+// for "/" and "~". The empty pointer designates the schema itself.
+//
+// RFC 6901
+func (t *Schema) At(pointer string) (*Schema, error) {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	s := t
+	for i := 0; i < len(tokens); {
+		child, consumed := s.child(tokens[i], tokenAt(tokens, i+1), i+1 < len(tokens))
+		if child == nil {
+			return nil, fmt.Errorf("jsonschema: no schema at %q", joinPointer(tokens[:i+consumed]))
+		}
+		s = child
+		i += consumed
+	}
+	return s, nil
+}
+
+// Set replaces, or adds, the sub-schema designated by a JSON Pointer relative
+// to the schema, see At. The schema holding the keyword must exist. Elements
+// of "allOf", "anyOf", "oneOf" and "prefixItems" are appended with the index
+// "-" or the length of the list. A nil sub-schema removes the keyword or the
+// named entry of "properties", "$defs" and the other maps; elements cannot be
+// removed from lists.
+func (t *Schema) Set(pointer string, sub *Schema) error {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return errors.New("jsonschema: cannot replace the root schema")
+	}
+	s := t
+	for i := 0; i < len(tokens); {
+		hasNext := i+1 < len(tokens)
+		consumed := childTokens(tokens[i], hasNext)
+		if i+consumed == len(tokens) {
+			if err := s.setChild(tokens[i], tokenAt(tokens, i+1), hasNext, sub); err != nil {
+				return fmt.Errorf("jsonschema: cannot set %q: %w", pointer, err)
+			}
+			return nil
+		}
+		child, _ := s.child(tokens[i], tokenAt(tokens, i+1), hasNext)
+		if child == nil {
+			return fmt.Errorf("jsonschema: no schema at %q", joinPointer(tokens[:i+consumed]))
+		}
+		s = child
+		i += consumed
+	}
+	return nil
+}
+
+// pointerTokens splits a JSON Pointer into its unescaped tokens.
+func pointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("jsonschema: invalid JSON Pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = unescapePointerToken(token)
+	}
+	return tokens, nil
+}
+
+func joinPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(escapePointerToken(token))
+	}
+	return b.String()
+}
+
+func tokenAt(tokens []string, i int) string {
+	if i < len(tokens) {
+		return tokens[i]
+	}
+	return ""
+}
+
+// childTokens reports how many tokens designate a sub-schema of the keyword:
+// two for the keywords holding several sub-schemas, one otherwise.
+func childTokens(keyword string, hasNext bool) int {
+	switch keyword {
+	case "$defs", "dependentSchemas", "patternProperties", "properties", "allOf", "anyOf", "oneOf", "prefixItems":
+		if hasNext {
+			return 2
+		}
+	}
+	return 1
+}
+
+// setChild sets the sub-schema of the keyword, see Set.
+func (t *Schema) setChild(keyword, name string, hasName bool, sub *Schema) error {
+	if t.boolean != nil {
+		return errors.New("boolean schemas have no keywords")
+	}
+	for _, kw := range singleKeywords {
+		if kw.name == keyword {
+			*kw.field(t) = sub
+			return nil
+		}
+	}
+	if !hasName {
+		if childTokens(keyword, true) == 2 {
+			return fmt.Errorf("%q holds several schemas, a name or index is expected", keyword)
+		}
+		return fmt.Errorf("%q is not a keyword taking a schema", keyword)
+	}
+	switch keyword {
+	case "$defs":
+		setEntry((*map[string]*Schema)(&t.Definitions), name, sub)
+	case "dependentSchemas":
+		setEntry(&t.DependentSchemas, name, sub)
+	case "patternProperties":
+		setEntry(&t.PatternProperties, name, sub)
+	case "properties":
+		switch {
+		case sub == nil:
+			t.Properties.Delete(name)
+		case t.Properties == nil:
+			t.Properties = NewProperties()
+			fallthrough
+		default:
+			t.Properties.Set(name, sub)
+		}
+	case "allOf":
+		return setItem(&t.AllOf, name, sub)
+	case "anyOf":
+		return setItem(&t.AnyOf, name, sub)
+	case "oneOf":
+		return setItem(&t.OneOf, name, sub)
+	case "prefixItems":
+		return setItem(&t.PrefixItems, name, sub)
+	default:
+		return fmt.Errorf("%q is not a keyword taking a schema", keyword)
+	}
+	return nil
+}
+
+func setEntry(m *map[string]*Schema, name string, sub *Schema) {
+	if sub == nil {
+		delete(*m, name)
+		return
+	}
+	if *m == nil {
+		*m = make(map[string]*Schema)
+	}
+	(*m)[name] = sub
+}
+
+func setItem(list *[]*Schema, token string, sub *Schema) error {
+	i, ok := arrayIndex(token)
+	if token == "-" {
+		i, ok = len(*list), true
+	}
+	switch {
+	case !ok || i > len(*list):
+		return fmt.Errorf("invalid array index %q", token)
+	case sub == nil:
+		return errors.New("cannot remove an element of a list")
+	case i == len(*list):
+		*list = append(*list, sub)
+	default:
+		(*list)[i] = sub
+	}
+	return nil
+}
+
+// child returns the sub-schema of the keyword, using the next token when the
+// keyword holds several sub-schemas, and reports how many tokens were used.
+func (t *Schema) child(keyword, next string, hasNext bool) (*Schema, int) {
+	if t == nil || t.boolean != nil {
+		return nil, 1
+	}
+	for _, kw := range singleKeywords {
+		if kw.name == keyword {
+			return *kw.field(t), 1
+		}
+	}
+	if !hasNext {
+		return nil, 1
+	}
+	switch keyword {
+	case "$defs":
+		return t.Definitions[next], 2
+	case "dependentSchemas":
+		return t.DependentSchemas[next], 2
+	case "patternProperties":
+		return t.PatternProperties[next], 2
+	case "properties":
+		s, _ := t.Properties.Get(next)
+		return s, 2
+	case "allOf":
+		return itemAt(t.AllOf, next), 2
+	case "anyOf":
+		return itemAt(t.AnyOf, next), 2
+	case "oneOf":
+		return itemAt(t.OneOf, next), 2
+	case "prefixItems":
+		return itemAt(t.PrefixItems, next), 2
+	}
+	return nil, 1
+}
+
+// itemAt returns the element of the list at the array index token.
+func itemAt(list []*Schema, token string) *Schema {
+	i, ok := arrayIndex(token)
+	if !ok || i >= len(list) {
+		return nil
+	}
+	return list[i]
+}
+
+// arrayIndex parses an array index token, which has no sign or leading zero.
+//
+// RFC 6901 section 4
+func arrayIndex(token string) (int, bool) {
+	if token == "" || token[0] < '0' || token[0] > '9' || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	i, err := strconv.Atoi(token)
+	return i, err == nil
+}
@@ -0,0 +1,99 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaAt(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$defs": {"a/b": {"type": "string"}, "m~n": {"type": "integer"}},
+		"properties": {"list": {"prefixItems": [true, {"minimum": 1}]}},
+		"patternProperties": {"^x/": {"not": {"type": "null"}}},
+		"dependentSchemas": {"card": {"required": ["billing"]}},
+		"allOf": [{"if": {"minProperties": 1}, "then": {"maxProperties": 3}}]
+	}`)
+
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{"/$defs/a~1b", `{"type":"string"}`},
+		{"/$defs/m~0n", `{"type":"integer"}`},
+		{"/properties/list/prefixItems/0", `true`},
+		{"/properties/list/prefixItems/1", `{"minimum":1}`},
+		{"/patternProperties/^x~1/not", `{"type":"null"}`},
+		{"/dependentSchemas/card", `{"required":["billing"]}`},
+		{"/allOf/0/then", `{"maxProperties":3}`},
+	}
+	for _, tt := range tests {
+		sub, err := s.At(tt.pointer)
+		require.NoError(t, err, tt.pointer)
+		data, err := sub.MarshalJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(data), tt.pointer)
+	}
+
+	root, err := s.At("")
+	require.NoError(t, err)
+	assert.Same(t, s, root)
+
+	for pointer, msg := range map[string]string{
+		"/$defs/a/b":                       `no schema at "/$defs/a"`,
+		"/$defs/m~0n/items":                `no schema at "/$defs/m~0n/items"`,
+		"/properties/list/prefixItems/01":  `no schema at "/properties/list/prefixItems/01"`,
+		"/properties/list/prefixItems/0/x": `no schema at "/properties/list/prefixItems/0/x"`,
+		"$defs":                            `invalid JSON Pointer "$defs"`,
+	} {
+		_, err := s.At(pointer)
+		assert.ErrorContains(t, err, msg, pointer)
+	}
+}
+
+func TestSchemaSet(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{"properties": {"name": {"type": "string"}}, "allOf": [{"minProperties": 1}]}`)
+
+	require.NoError(t, s.Set("/$defs/a~1b", &Schema{Type: "integer"}))
+	require.NoError(t, s.Set("/properties/name/not", &Schema{Const: "root"}))
+	require.NoError(t, s.Set("/properties/x~0y", FalseSchema))
+	require.NoError(t, s.Set("/patternProperties/^a", &Schema{Type: "boolean"}))
+	require.NoError(t, s.Set("/allOf/0", mustUnmarshalSchema(t, `{"minProperties":2}`)))
+	require.NoError(t, s.Set("/allOf/1", mustUnmarshalSchema(t, `{"maxProperties":5}`)))
+	require.NoError(t, s.Set("/allOf/-", &Schema{Ref: "#/$defs/a~1b"}))
+	require.NoError(t, s.Set("/allOf/2/items", TrueSchema))
+
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$defs": {"a/b": {"type": "integer"}},
+		"properties": {"name": {"type": "string", "not": {"const": "root"}}, "x~y": false},
+		"patternProperties": {"^a": {"type": "boolean"}},
+		"allOf": [{"minProperties": 2}, {"maxProperties": 5}, {"$ref": "#/$defs/a~1b", "items": true}]
+	}`, string(data))
+
+	require.NoError(t, s.Set("/properties/name", nil))
+	require.NoError(t, s.Set("/$defs/a~1b", nil))
+	require.NoError(t, s.Set("/allOf/2/items", nil))
+	data, err = s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"properties": {"x~y": false},
+		"patternProperties": {"^a": {"type": "boolean"}},
+		"allOf": [{"minProperties": 2}, {"maxProperties": 5}, {"$ref": "#/$defs/a~1b"}]
+	}`, string(data))
+
+	for pointer, msg := range map[string]string{
+		"":                    "cannot replace the root schema",
+		"/allOf/4":            `cannot set "/allOf/4": invalid array index "4"`,
+		"/allOf":              `cannot set "/allOf": "allOf" holds several schemas`,
+		"/$defs":              `cannot set "/$defs": "$defs" holds several schemas`,
+		"/type":               `cannot set "/type": "type" is not a keyword taking a schema`,
+		"/properties/x~0y/if": `cannot set "/properties/x~0y/if": boolean schemas have no keywords`,
+		"/$defs/missing/not":  `no schema at "/$defs/missing"`,
+	} {
+		assert.ErrorContains(t, s.Set(pointer, TrueSchema), msg, pointer)
+	}
+	assert.ErrorContains(t, s.Set("/allOf/0", nil), "cannot remove an element of a list")
+}
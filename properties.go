@@ -1,6 +1,11 @@
 package jsonschema
 
 import (
+	"bytes"
+	"encoding/json/jsontext"
+	"fmt"
+	"iter"
+
 	jsonv1 "github.com/goccy/go-json"
 )
 
@@ -66,6 +71,20 @@ func (p *Properties) Delete(key string) {
 	}
 }
 
+// All iterates over the properties in insertion order.
+func (p *Properties) All() iter.Seq2[string, *Schema] {
+	return func(yield func(string, *Schema) bool) {
+		if p == nil {
+			return
+		}
+		for _, key := range p.order {
+			if !yield(key, p.values[key]) {
+				return
+			}
+		}
+	}
+}
+
 func (p *Properties) MarshalJSON() ([]byte, error) {
 	if p == nil {
 		return []byte("null"), nil
@@ -94,20 +113,38 @@ func (p *Properties) MarshalJSON() ([]byte, error) {
 	return buf, nil
 }
 
+// UnmarshalJSON decodes the properties, keeping their order in the document.
 func (p *Properties) UnmarshalJSON(data []byte) error {
 	if string(data) == "null" {
 		return nil
 	}
-	var m map[string]*Schema
-	if err := jsonv1.Unmarshal(data, &m); err != nil {
+	dec := jsontext.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.ReadToken()
+	if err != nil {
 		return err
 	}
-	if p.values == nil {
-		p.values = make(map[string]*Schema, len(m))
+	if tok.Kind() != '{' {
+		return fmt.Errorf("jsonschema: properties must be an object, got %s", tok.Kind())
 	}
-	for k, v := range m {
-		p.order = append(p.order, k)
-		p.values[k] = v
+	for dec.PeekKind() != '}' {
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return err
+		}
+		name := tok.String()
+		value, err := dec.ReadValue()
+		if err != nil {
+			return err
+		}
+		var s *Schema
+		if value.Kind() != 'n' {
+			s = new(Schema)
+			if err := s.UnmarshalJSON(value); err != nil {
+				return err
+			}
+		}
+		p.Set(name, s)
 	}
-	return nil
+	_, err = dec.ReadToken()
+	return err
 }
@@ -8,6 +8,7 @@ package jsonschema
 
 import (
 	"bytes"
+	"encoding/json/jsontext"
 	json "encoding/json/v2"
 	"fmt"
 	jsonv1 "github.com/goccy/go-json"
@@ -174,6 +175,15 @@ type Reflector struct {
 	// validated JSON is unmarshaled.
 	AllowAdditionalProperties bool
 
+	// UnevaluatedProperties will cause the Reflector to close struct types with
+	// `unevaluatedProperties: false` instead of `additionalProperties: false`.
+	// Unlike additionalProperties, unevaluatedProperties also takes into
+	// account the properties declared by sub-schemas in allOf, anyOf, oneOf,
+	// if/then/else and $ref, so struct schemas extended through those keywords
+	// (for example with JSONSchemaExtend) stay closed without rejecting the
+	// properties they inherit. It has no effect with AllowAdditionalProperties.
+	UnevaluatedProperties bool
+
 	// RequiredFromJSONSchemaTags will cause the Reflector to generate a schema
 	// that requires any key tagged with `jsonschema:required`, overriding the
 	// default of requiring any key *not* tagged with `json:,omitempty`.
@@ -191,6 +201,12 @@ type Reflector struct {
 	// FieldNameTag will change the tag used to get field names. json tags are used by default.
 	FieldNameTag string
 
+	// ContentSchemaTypes defines the types that `contentSchema` tags may name
+	// without them being part of the reflected schema otherwise. A named type
+	// is reflected into the definitions, and naming a type that is neither
+	// reflected nor listed here panics.
+	ContentSchemaTypes []any
+
 	// IgnoredTypes defines a slice of types that should be ignored in the schema,
 	// switching to just allowing additional properties instead.
 	IgnoredTypes []any
@@ -245,8 +261,40 @@ type Reflector struct {
 	// See also: AddGoComments, LookupComment
 	CommentMap map[string]string
 
+	// CheckMetaSchema when true will validate every schema generated by
+	// ReflectFromType against the 2020-12 meta-schema, see
+	// Schema.CheckMetaSchema. This catches the invalid output of JSONSchema
+	// methods, JSONSchemaExtend hooks and Mapper functions early: ReflectFromType
+	// panics with the validation error, just as it does for unsupported types.
+	CheckMetaSchema bool
+
+	// Dialect when set will convert every schema generated by ReflectFromType
+	// to an older version of JSON Schema, such as Draft07, for consumers that
+	// do not support 2020-12. Keywords with no equivalent in the dialect are
+	// kept as they are and reported to DialectWarnings.
+	Dialect Dialect
+
+	// DialectWarnings when set is called with the type and the warnings of
+	// the conversion to Dialect, for every schema generated by
+	// ReflectFromType that the dialect cannot fully express. See
+	// Schema.ConvertTo.
+	DialectWarnings func(t reflect.Type, warnings []Warning)
+
+	// FingerprintID when true will set the $id of every schema generated by
+	// ReflectFromType from its Fingerprint, computed without $id, for
+	// registries keyed by content hash. The fingerprint is added to
+	// BaseSchemaID when set, such as "https://example.com/schemas/{hex}", and
+	// the $id is "urn:sha256:{hex}" otherwise.
+	FingerprintID bool
+
 	// fieldCache stores per-type field metadata to avoid re-parsing tags on every reflection.
 	fieldCache fieldCache
+
+	// validators stores the compiled schema of each type checked by
+	// ValidateValue. It is allocated on first use and held by pointer so
+	// that copies of the Reflector share it instead of copying a sync.Map.
+	validators     *sync.Map
+	validatorsOnce sync.Once
 }
 
 // Reflect reflects to Schema from a value.
@@ -279,7 +327,7 @@ func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
 		if baseSchemaID == EmptyID {
 			pkgPath := remapPkgPath(t.PkgPath())
 			id := ID("https://" + pkgPath)
-			if err := id.Validate(); err == nil {
+			if err := id.ValidateStrict(); err == nil {
 				baseSchemaID = id
 			}
 		}
@@ -295,11 +343,43 @@ func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
 		s.ID = EmptyID
 	}
 
+	r.resolveContentSchemas(definitions, s)
+
 	s.Version = Version
 	if !r.DoNotReference {
 		s.Definitions = definitions
 	}
 
+	if r.FingerprintID {
+		s.ID = EmptyID
+		fingerprint, err := s.Fingerprint()
+		if err != nil {
+			panic(err)
+		}
+		if r.BaseSchemaID != EmptyID {
+			s.ID = r.BaseSchemaID.Add(fingerprint)
+		} else {
+			s.ID = ID("urn:sha256:" + fingerprint)
+		}
+	}
+
+	if r.CheckMetaSchema {
+		if err := s.CheckMetaSchema(); err != nil {
+			panic(fmt.Errorf("jsonschema: invalid schema reflected from %s: %w", t, err))
+		}
+	}
+
+	if r.Dialect != "" && r.Dialect != Draft202012 {
+		converted, warnings, err := s.ConvertTo(r.Dialect)
+		if err != nil {
+			panic(err)
+		}
+		if len(warnings) > 0 && r.DialectWarnings != nil {
+			r.DialectWarnings(t, warnings)
+		}
+		s = converted
+	}
+
 	return s
 }
 
@@ -521,7 +601,7 @@ func (r *Reflector) reflectMap(definitions Definitions, name string, tag reflect
 		st.PatternProperties = map[string]*Schema{
 			"^[0-9]+$": r.refOrReflectTypeToSchema(definitions, name, tag, t.Elem()),
 		}
-		st.AdditionalProperties = FalseSchema
+		st.AdditionalProperties = FalseSchema.Clone()
 		return
 	}
 	if t.Elem().Kind() != reflect.Interface {
@@ -551,7 +631,11 @@ func (r *Reflector) reflectStruct(definitions Definitions, name string, tag refl
 		s.Anchor = t.Name()
 	}
 	if !r.AllowAdditionalProperties && s.AdditionalProperties == nil {
-		s.AdditionalProperties = FalseSchema
+		if r.UnevaluatedProperties {
+			s.UnevaluatedProperties = FalseSchema.Clone()
+		} else {
+			s.AdditionalProperties = FalseSchema.Clone()
+		}
 	}
 
 	ignored := false
@@ -643,15 +727,7 @@ func (r *Reflector) reflectStructFields(definitions Definitions, pName string, t
 	}
 
 	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		meta, ok := r.fieldCache.get(t, i)
-		if !ok {
-			name, shouldEmbed, required, nullable := r.reflectFieldName(f)
-			schemaTags := splitOnUnescapedCommas(f.Tag.Get("jsonschema"))
-			meta = cachedField{name: name, embed: shouldEmbed, required: required, nullable: nullable, schemaTags: schemaTags}
-			r.fieldCache.set(t, i, meta)
-		}
-		handleField(f, meta)
+		handleField(t.Field(i), r.fieldMeta(t, i))
 	}
 	if r.AdditionalFields != nil {
 		if af := r.AdditionalFields(t); af != nil {
@@ -664,6 +740,20 @@ func (r *Reflector) reflectStructFields(definitions Definitions, pName string, t
 	}
 }
 
+// fieldMeta provides the cached metadata of the i-th field of the struct type t,
+// parsing its tags the first time it is requested.
+func (r *Reflector) fieldMeta(t reflect.Type, i int) cachedField {
+	if meta, ok := r.fieldCache.get(t, i); ok {
+		return meta
+	}
+	f := t.Field(i)
+	name, shouldEmbed, required, nullable := r.reflectFieldName(f)
+	schemaTags := splitOnUnescapedCommas(f.Tag.Get("jsonschema"))
+	meta := cachedField{name: name, embed: shouldEmbed, required: required, nullable: nullable, schemaTags: schemaTags}
+	r.fieldCache.set(t, i, meta)
+	return meta
+}
+
 func appendUniqueString(base []string, value string) []string {
 	for v := range slices.Values(base) {
 		if v == value {
@@ -864,6 +954,12 @@ func (t *Schema) stringKeywords(tags []string) {
 			t.Pattern = val
 		case "format":
 			t.Format = val
+		case "contentEncoding":
+			t.ContentEncoding = val
+		case "contentMediaType":
+			t.ContentMediaType = val
+		case "contentSchema":
+			t.ContentSchema = contentSchemaRef(val)
 		case "readOnly":
 			i, _ := strconv.ParseBool(val)
 			t.ReadOnly = i
@@ -880,6 +976,70 @@ func (t *Schema) stringKeywords(tags []string) {
 	}
 }
 
+// contentSchemaRef references the schema of the content of a string. A plain
+// type name refers to the definition of that type, see
+// Reflector.ContentSchemaTypes; any other value is used as the reference
+// itself.
+func contentSchemaRef(val string) *Schema {
+	if !strings.ContainsAny(val, "#/:") {
+		val = "#/$defs/" + escapePointerToken(val)
+	}
+	return &Schema{Ref: val}
+}
+
+// resolveContentSchemas makes sure that the definitions referenced by
+// "contentSchema" exist, reflecting the types of ContentSchemaTypes they
+// name. With DoNotReference, the definitions are not part of the output and
+// are inlined instead.
+func (r *Reflector) resolveContentSchemas(definitions Definitions, root *Schema) {
+	for {
+		// The schemas are shared between the root and the definitions.
+		pending := make(map[*Schema]string)
+		collect := func(_ Pointer, s *Schema) error {
+			if s.ContentSchema != nil && strings.HasPrefix(s.ContentSchema.Ref, "#/$defs/") {
+				pending[s] = unescapePointerToken(strings.TrimPrefix(s.ContentSchema.Ref, "#/$defs/"))
+			}
+			return nil
+		}
+		_ = Walk(root, collect)
+		for _, name := range sortedKeys(definitions) {
+			_ = Walk(definitions[name], collect)
+		}
+
+		added := false
+		for s, name := range pending {
+			if _, ok := definitions[name]; !ok {
+				t := r.contentSchemaType(name)
+				if t == nil {
+					panic(fmt.Errorf("jsonschema: contentSchema names the unknown type %q, see Reflector.ContentSchemaTypes", name))
+				}
+				r.refOrReflectTypeToSchema(definitions, name, "", t)
+				added = true
+			}
+			if r.DoNotReference {
+				s.ContentSchema = definitions[name]
+			}
+		}
+		if !added {
+			return
+		}
+	}
+}
+
+// contentSchemaType returns the type of ContentSchemaTypes with the name.
+func (r *Reflector) contentSchemaType(name string) reflect.Type {
+	for _, v := range r.ContentSchemaTypes {
+		t := reflect.TypeOf(v)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if r.typeName(t) == name {
+			return t
+		}
+	}
+	return nil
+}
+
 // read struct tags for numerical type keywords
 func (t *Schema) numericalKeywords(tags []string) {
 	for _, tag := range tags {
@@ -1164,22 +1324,65 @@ func (t *Schema) UnmarshalJSON(data []byte) error {
 
 	type SchemaAlt Schema
 
+	// Const holds the raw value of "const" since null decodes as no value,
+	// and Unknown the keywords that are not fields of Schema.
 	s := struct {
 		*SchemaAlt `json:",inline"`
-		TypeUnion  *typeUnion `json:"type"`
+		TypeUnion  *typeUnion                `json:"type"`
+		Const      jsontext.Value            `json:"const"`
+		Unknown    map[string]jsontext.Value `json:",embed"`
 	}{
 		SchemaAlt: (*SchemaAlt)(t),
 		TypeUnion: &typeUnion{},
 	}
 
-	err := json.Unmarshal(data, &s)
-	if err != nil {
+	if err := json.Unmarshal(data, &s); err != nil {
 		return err
 	}
 
 	s.TypeEnhanced = s.TypeUnion.TypeEnhanced
 	s.Type = s.TypeUnion.Type
 
+	switch {
+	case s.Const == nil:
+	case s.Const.Kind() == 'n':
+		t.Const = Null
+	default:
+		t.Const = nil
+		if err := json.Unmarshal(s.Const, &t.Const); err != nil {
+			return err
+		}
+	}
+
+	return t.unmarshalExtras(s.Unknown)
+}
+
+// schemaKeywords are the JSON names of the fields of Schema.
+var schemaKeywords = sync.OnceValue(func() map[string]bool {
+	names := map[string]bool{"type": true}
+	st := reflect.TypeFor[Schema]()
+	for i := range st.NumField() {
+		name, _, _ := strings.Cut(st.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+})
+
+// unmarshalExtras keeps the keywords of the schema object that are not
+// defined by the specification in Extras, see RegisterKeyword.
+func (t *Schema) unmarshalExtras(members map[string]jsontext.Value) error {
+	for _, name := range sortedKeys(members) {
+		v, err := decodeExtra(name, members[name])
+		if err != nil {
+			return err
+		}
+		if t.Extras == nil {
+			t.Extras = make(map[string]any)
+		}
+		t.Extras[name] = v
+	}
 	return nil
 }
 
@@ -417,6 +417,7 @@ func TestSchemaGeneration(t *testing.T) {
 			},
 		}, "fixtures/lookup_expanded.json"},
 		{&Outer{}, &Reflector{ExpandedStruct: true}, "fixtures/inlining_inheritance.json"},
+		{&Outer{}, &Reflector{ExpandedStruct: true, UnevaluatedProperties: true}, "fixtures/unevaluated_properties.json"},
 		{&OuterNamed{}, &Reflector{ExpandedStruct: true}, "fixtures/inlining_embedded.json"},
 		{&OuterNamed{}, &Reflector{ExpandedStruct: true, AssignAnchor: true}, "fixtures/inlining_embedded_anchored.json"},
 		{&OuterInlined{}, &Reflector{ExpandedStruct: true}, "fixtures/inlining_tag.json"},
@@ -442,6 +443,8 @@ func TestSchemaGeneration(t *testing.T) {
 		{&CustomTypeFieldWithInterface{}, &Reflector{}, "fixtures/custom_type_with_interface.json"},
 		{&PatternTest{}, &Reflector{}, "fixtures/commas_in_pattern.json"},
 		{&RecursiveExample{}, &Reflector{}, "fixtures/recursive.json"},
+		{&RecursiveExample{}, &Reflector{Dialect: Draft07}, "fixtures/recursive_draft07.json"},
+		{&TestUser{}, &Reflector{Dialect: Draft04}, "fixtures/test_user_draft04.json"},
 		{&KeyNamed{}, &Reflector{
 			KeyNamer: func(s string) string {
 				switch s {
@@ -579,6 +582,50 @@ func TestFieldOneOfRef(t *testing.T) {
 	compareSchemaOutput(t, "fixtures/oneof_ref.json", r, &Server{})
 }
 
+func TestContentHandling(t *testing.T) {
+	type Payload struct {
+		ID int `json:"id"`
+	}
+	type Message struct {
+		Payload  Payload `json:"payload"`
+		Raw      []byte  `json:"raw" jsonschema:"contentMediaType=application/json,contentSchema=Payload"`
+		Document string  `json:"document" jsonschema:"contentEncoding=base32,contentMediaType=application/vnd.example+json,contentSchema=#/$defs/Payload"`
+	}
+
+	r := &Reflector{}
+	compareSchemaOutput(t, "fixtures/content_handling.json", r, &Message{})
+	fixtureContains(t, "fixtures/content_handling.json", `"contentEncoding": "base64"`)
+	fixtureContains(t, "fixtures/content_handling.json", `"$ref": "#/$defs/Payload"`)
+}
+
+func TestContentSchemaTypes(t *testing.T) {
+	type Envelope struct {
+		Body []byte `json:"body" jsonschema:"contentMediaType=application/json,contentSchema=Payload"`
+	}
+	type Payload struct {
+		ID int `json:"id"`
+	}
+
+	assert.PanicsWithError(t, `jsonschema: contentSchema names the unknown type "Payload", see Reflector.ContentSchemaTypes`, func() {
+		(&Reflector{}).Reflect(&Envelope{})
+	})
+
+	s := (&Reflector{ContentSchemaTypes: []any{Payload{}}}).Reflect(&Envelope{})
+	require.Contains(t, s.Definitions, "Payload")
+	body, err := s.At("/$defs/Envelope/properties/body")
+	require.NoError(t, err)
+	assert.Equal(t, "#/$defs/Payload", body.ContentSchema.Ref)
+
+	s = (&Reflector{ContentSchemaTypes: []any{&Payload{}}, DoNotReference: true}).Reflect(&Envelope{})
+	assert.Nil(t, s.Definitions)
+	body, err = s.At("/properties/body")
+	require.NoError(t, err)
+	assert.Empty(t, body.ContentSchema.Ref)
+	id, ok := body.ContentSchema.Properties.Get("id")
+	require.True(t, ok)
+	assert.Equal(t, "integer", id.Type)
+}
+
 func TestNumberHandling(t *testing.T) {
 	type NumberHandler struct {
 		Int64   int64   `json:"int64" jsonschema:"default=12"`
@@ -707,6 +754,73 @@ func TestUnmarshalSchemaType(t *testing.T) {
 	require.NotNil(t, sc.TypeEnhanced)
 }
 
+func TestConstNull(t *testing.T) {
+	sc := &Schema{}
+	require.NoError(t, json.Unmarshal([]byte(`{"const":null}`), sc))
+	assert.Equal(t, Null, sc.Const)
+	b, err := json.Marshal(sc)
+	require.NoError(t, err)
+	assert.Equal(t, `{"const":null}`, string(b))
+	assert.NoError(t, sc.Validate(nil))
+	assert.Error(t, sc.Validate(0))
+	assert.False(t, Equal(sc, &Schema{}))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"const":0}`), sc))
+	assert.NotEqual(t, Null, sc.Const)
+}
+
+func TestUnmarshalPropertiesOrder(t *testing.T) {
+	sc := &Schema{}
+	require.NoError(t, json.Unmarshal([]byte(`{"properties":{"zeta":true,"alpha":{"type":"string"},"mid":false,"none":null}}`), sc))
+	assert.Equal(t, []string{"zeta", "alpha", "mid", "none"}, sc.Properties.order)
+	assert.Nil(t, sc.Properties.values["none"])
+
+	b, err := json.Marshal(sc)
+	require.NoError(t, err)
+	assert.Equal(t, `{"properties":{"zeta":true,"alpha":{"type":"string"},"mid":false,"none":null}}`, string(b))
+
+	require.Error(t, json.Unmarshal([]byte(`{"properties":[]}`), &Schema{}))
+}
+
+func TestSchemaDynamicKeywordsRoundTrip(t *testing.T) {
+	const data = `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://json-schema.org/draft/2020-12/schema",
+		"$vocabulary": {
+			"https://json-schema.org/draft/2020-12/vocab/core": true,
+			"https://json-schema.org/draft/2020-12/vocab/applicator": true,
+			"https://json-schema.org/draft/2020-12/vocab/format-assertion": false
+		},
+		"$dynamicAnchor": "meta",
+		"title": "Core and Validation specifications meta-schema",
+		"allOf": [
+			{"$ref": "meta/core"},
+			{"$ref": "meta/applicator"}
+		],
+		"type": ["object", "boolean"],
+		"properties": {
+			"definitions": {
+				"type": "object",
+				"additionalProperties": {"$dynamicRef": "#meta"},
+				"deprecated": true
+			}
+		}
+	}`
+
+	sc := &Schema{}
+	require.NoError(t, json.Unmarshal([]byte(data), sc))
+	assert.Equal(t, "meta", sc.DynamicAnchor)
+	assert.Equal(t, map[string]bool{
+		"https://json-schema.org/draft/2020-12/vocab/core":             true,
+		"https://json-schema.org/draft/2020-12/vocab/applicator":       true,
+		"https://json-schema.org/draft/2020-12/vocab/format-assertion": false,
+	}, sc.Vocabulary)
+
+	b, err := json.Marshal(sc)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(b))
+}
+
 func TestSchemaModifierFn(t *testing.T) {
 	type TestInnerB struct {
 		B string   `json:"b" jsonschema:"title=__TestStringB"`
@@ -0,0 +1,152 @@
+package jsonschema
+
+import (
+	json "encoding/json/v2"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultValidateReflector is the Reflector used by ValidateValue, which
+// keeps the compiled schemas of the types across calls.
+var defaultValidateReflector = &Reflector{}
+
+// ValidateValue validates a Go value against the schema reflected from its
+// type using the default Reflector. See Reflector.ValidateValue.
+func ValidateValue(v any) error {
+	return defaultValidateReflector.ValidateValue(v)
+}
+
+// ValidateValue reflects the type of v with the Reflector's options, encodes
+// v the same way encoding/json/v2 would and validates the result. This makes
+// it possible to enforce the constraints declared in `jsonschema` struct tags,
+// for example before persisting a value.
+//
+// The schema of each type is compiled once and reused by later calls, so the
+// Reflector's options should not be changed after the first call.
+//
+// Validation failures are reported as a *ValidationError whose leaves also
+// carry the FieldPath of the failing Go field, such as `User.Friends[2]`.
+func (r *Reflector) ValidateValue(v any) error {
+	if v == nil {
+		return errors.New("jsonschema: cannot validate a nil value")
+	}
+	t := reflect.TypeOf(v)
+	validator, err := r.validatorFor(t)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("jsonschema: cannot encode value: %w", err)
+	}
+	inst, err := decodeInstance(data)
+	if err != nil {
+		return err
+	}
+
+	err = validator.Validate(inst)
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		r.setFieldPaths(verr, t)
+	}
+	return err
+}
+
+// validatorCache returns the compiled schemas of the Reflector, keyed by type.
+func (r *Reflector) validatorCache() *sync.Map {
+	r.validatorsOnce.Do(func() { r.validators = new(sync.Map) })
+	return r.validators
+}
+
+func (r *Reflector) validatorFor(t reflect.Type) (*Validator, error) {
+	cache := r.validatorCache()
+	if v, ok := cache.Load(t); ok {
+		return v.(*Validator), nil
+	}
+	v, err := Compile(r.ReflectFromType(t))
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := cache.LoadOrStore(t, v)
+	return actual.(*Validator), nil
+}
+
+func (r *Reflector) setFieldPaths(e *ValidationError, t reflect.Type) {
+	e.FieldPath = r.fieldPath(t, e.InstanceLocation)
+	for _, c := range e.Causes {
+		r.setFieldPaths(c, t)
+	}
+}
+
+// fieldPath converts a JSON Pointer into the instance encoded from a value of
+// type t into the equivalent Go expression, for example `User.Friends[2]`.
+// When part of the pointer cannot be mapped to the Go type, such as a property
+// that does not exist on a struct, the path of the deepest known value is
+// returned.
+func (r *Reflector) fieldPath(t reflect.Type, pointer string) string {
+	t = derefType(t)
+	var b strings.Builder
+	if t.Name() != "" {
+		b.WriteString(t.Name())
+	} else {
+		b.WriteString(t.String())
+	}
+	if pointer == "" {
+		return b.String()
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescapePointerToken(token)
+		t = derefType(t)
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := r.fieldByJSONName(t, token)
+			if !ok {
+				return b.String()
+			}
+			b.WriteByte('.')
+			b.WriteString(f.Name)
+			t = f.Type
+		case reflect.Slice, reflect.Array:
+			if _, err := strconv.Atoi(token); err != nil {
+				return b.String()
+			}
+			b.WriteString("[" + token + "]")
+			t = t.Elem()
+		case reflect.Map:
+			b.WriteString("[" + strconv.Quote(token) + "]")
+			t = t.Elem()
+		default:
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+// fieldByJSONName finds the struct field encoded with the given property name,
+// looking through embedded structs whose fields are inlined.
+func (r *Reflector) fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		meta := r.fieldMeta(t, i)
+		if meta.name == name {
+			return t.Field(i), true
+		}
+		if meta.embed {
+			if f, ok := r.fieldByJSONName(derefType(t.Field(i).Type), name); ok {
+				return f, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
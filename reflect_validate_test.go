@@ -0,0 +1,82 @@
+package jsonschema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateValueFriend struct {
+	Name string `json:"name" jsonschema:"minLength=1"`
+}
+
+type validateValueAudit struct {
+	CreatedBy string `json:"created_by" jsonschema:"pattern=^[a-z]+$"`
+}
+
+type validateValueUser struct {
+	validateValueAudit
+	ID      int                    `json:"id" jsonschema:"minimum=1"`
+	Email   string                 `json:"email,omitempty" jsonschema:"pattern=@"`
+	Friends []*validateValueFriend `json:"friends,omitempty" jsonschema:"maxItems=3"`
+	Labels  map[string]string      `json:"labels,omitempty" jsonschema:"additionalProperties=true"`
+	Scores  []int                  `json:"scores,omitempty"`
+}
+
+func TestValidateValue(t *testing.T) {
+	valid := &validateValueUser{
+		validateValueAudit: validateValueAudit{CreatedBy: "admin"},
+		ID:                 1,
+		Friends:            []*validateValueFriend{{Name: "Ann"}},
+	}
+	assert.NoError(t, ValidateValue(valid))
+	assert.NoError(t, ValidateValue(*valid))
+
+	err := ValidateValue(&validateValueUser{
+		validateValueAudit: validateValueAudit{CreatedBy: "Admin"},
+		ID:                 0,
+		Friends:            []*validateValueFriend{{Name: "Ann"}, {Name: "Bob"}, {}},
+	})
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	paths := map[string]string{}
+	for _, leaf := range verr.leaves(nil) {
+		paths[leaf.InstanceLocation] = leaf.FieldPath
+	}
+	assert.Equal(t, map[string]string{
+		"/created_by":     "validateValueUser.CreatedBy",
+		"/friends/2/name": "validateValueUser.Friends[2].Name",
+		"/id":             "validateValueUser.ID",
+	}, paths)
+	assert.Contains(t, err.Error(), "/friends/2/name (validateValueUser.Friends[2].Name): ")
+
+	_, cached := defaultValidateReflector.validatorCache().Load(reflect.TypeFor[validateValueUser]())
+	assert.True(t, cached, "the compiled schema is reused by later calls")
+}
+
+func TestValidateValueNil(t *testing.T) {
+	assert.Error(t, ValidateValue(nil))
+}
+
+func TestReflectorFieldPath(t *testing.T) {
+	r := &Reflector{}
+	typ := reflect.TypeFor[validateValueUser]()
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{"", "validateValueUser"},
+		{"/friends/1/name", "validateValueUser.Friends[1].Name"},
+		{"/labels/a~1b", `validateValueUser.Labels["a/b"]`},
+		{"/scores/0", "validateValueUser.Scores[0]"},
+		{"/unknown/0", "validateValueUser"},
+		{"/created_by", "validateValueUser.CreatedBy"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, r.fieldPath(typ, tt.pointer), tt.pointer)
+	}
+}
@@ -0,0 +1,272 @@
+package jsonschema
+
+import (
+	json "encoding/json/v2"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ErrSchemaNotFound is returned, possibly wrapped, by a Loader that has no
+// document for the requested URI.
+var ErrSchemaNotFound = errors.New("jsonschema: schema not found")
+
+// Loader fetches schema documents by their absolute URI, without fragment.
+// A Loader that does not know the URI returns an error wrapping
+// ErrSchemaNotFound so that the next Loader of a Registry is tried, which is
+// also the case when it returns a nil schema without error.
+type Loader interface {
+	Load(uri string) (*Schema, error)
+}
+
+// LoaderFunc is an adapter to use a function as a Loader.
+type LoaderFunc func(uri string) (*Schema, error)
+
+// Load calls f(uri).
+func (f LoaderFunc) Load(uri string) (*Schema, error) {
+	return f(uri)
+}
+
+// MapLoader is an in-memory Loader holding documents by their ID.
+type MapLoader map[ID]*Schema
+
+// Load returns the schema stored for the URI.
+func (m MapLoader) Load(uri string) (*Schema, error) {
+	if s, ok := m[ID(uri)]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrSchemaNotFound, uri)
+}
+
+// FSLoader returns a Loader reading the documents of the URIs starting with
+// base from fsys, which may be an embed.FS. The rest of the URI is used as the
+// path of the file, with a ".json" extension added when the file does not
+// exist without one. For example, with the base "https://example.com/schemas/"
+// the URI "https://example.com/schemas/user" is read from "user" or
+// "user.json".
+func FSLoader(fsys fs.FS, base string) Loader {
+	return LoaderFunc(func(uri string) (*Schema, error) {
+		rest, ok := strings.CutPrefix(uri, base)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrSchemaNotFound, uri)
+		}
+		name, err := url.PathUnescape(strings.TrimPrefix(rest, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid URI %q: %w", uri, err)
+		}
+		name = path.Clean(name)
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("%w: %q", ErrSchemaNotFound, uri)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) && path.Ext(name) == "" {
+			data, err = fs.ReadFile(fsys, name+".json")
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %q", ErrSchemaNotFound, uri)
+		} else if err != nil {
+			return nil, err
+		}
+		s := new(Schema)
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("jsonschema: cannot decode %q: %w", uri, err)
+		}
+		return s, nil
+	})
+}
+
+// DirLoader returns a Loader reading the documents of the URIs starting with
+// base from the directory, see FSLoader.
+func DirLoader(dir, base string) Loader {
+	return FSLoader(os.DirFS(dir), base)
+}
+
+// Registry indexes schema documents by the absolute URI of every schema
+// resource ("$id") and anchor ("$anchor" and "$dynamicAnchor") they contain,
+// fetching unknown documents with its loaders. It is safe for concurrent use.
+type Registry struct {
+	loaders []Loader
+
+	mu sync.Mutex
+	// index holds the schemas by absolute URI, always with a fragment, which
+	// is empty for schema resources.
+	index map[string]*Schema
+}
+
+// DefaultRegistry is the registry used by Schema.Resolve and, unless another
+// one is given with WithRegistry or WithLoader, by Compile to find the
+// documents of external references. It only loads the embedded 2020-12
+// meta-schemas, see MetaSchemaLoader, so other documents must be added to it.
+var DefaultRegistry = NewRegistry(MetaSchemaLoader)
+
+// NewRegistry creates a registry fetching unknown documents with the loaders,
+// which are tried in order.
+func NewRegistry(loaders ...Loader) *Registry {
+	return &Registry{loaders: loaders, index: make(map[string]*Schema)}
+}
+
+// Add indexes the document under its "$id", which must be an absolute URI.
+func (r *Registry) Add(s *Schema) error {
+	if s == nil {
+		return errors.New("jsonschema: cannot add a nil schema")
+	}
+	u, err := url.Parse(s.ID.String())
+	if err != nil || !u.IsAbs() {
+		return fmt.Errorf("jsonschema: schema $id %q is not an absolute URI", s.ID)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.add(s, s.ID.String())
+}
+
+// add indexes the document retrieved from the URI.
+func (r *Registry) add(s *Schema, uri string) error {
+	return indexSchema(r.index, s, stripFragment(uri))
+}
+
+// Resolve returns the schema designated by the absolute URI, which may have
+// a JSON Pointer or an anchor as fragment. The document is fetched with the
+// loaders of the registry when it is not already indexed.
+func (r *Registry) Resolve(uri string) (*Schema, error) {
+	abs, err := resolveURI("", uri)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid URI %q: %w", uri, err)
+	}
+	if _, err := r.document(stripFragment(abs)); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lookupSchema(r.index, abs)
+}
+
+// Load returns the schema resource of the URI, fetching it with the loaders of
+// the registry when needed. It makes a Registry usable as the Loader of another
+// one, or of Bundle.
+func (r *Registry) Load(uri string) (*Schema, error) {
+	return r.document(stripFragment(uri))
+}
+
+// document returns the indexed schema resource of the URI, loading it when
+// needed. The loaders are called without r.mu held since they may use the
+// registry themselves; when another call indexed the document meanwhile, the
+// loaded one is dropped.
+func (r *Registry) document(uri string) (*Schema, error) {
+	r.mu.Lock()
+	s, ok := r.index[uri+"#"]
+	r.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+	for _, l := range r.loaders {
+		s, err := l.Load(uri)
+		if errors.Is(err, ErrSchemaNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if indexed, ok := r.index[uri+"#"]; ok {
+			return indexed, nil
+		}
+		if err := r.add(s, uri); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrSchemaNotFound, uri)
+}
+
+// Resolve returns the schema designated by the reference, resolved against
+// the "$id" of the schema. The reference may designate the schema itself or
+// one of its sub-schemas by JSON Pointer, "$id" or anchor; other documents are
+// looked up in DefaultRegistry, see Registry.ResolveFrom to use another one.
+func (t *Schema) Resolve(ref string) (*Schema, error) {
+	return DefaultRegistry.ResolveFrom(t, ref)
+}
+
+// ResolveFrom returns the schema designated by a reference found in the
+// schema s, resolved against its "$id". The reference may designate s itself
+// or one of its sub-schemas by JSON Pointer, "$id" or anchor; other documents
+// are looked up in the registry.
+func (r *Registry) ResolveFrom(s *Schema, ref string) (*Schema, error) {
+	base, err := resolveURI("", s.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+	}
+	uri, err := resolveURI(stripFragment(base), ref)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid $ref %q: %w", ref, err)
+	}
+	local := make(map[string]*Schema)
+	if err := indexSchema(local, s, stripFragment(base)); err != nil {
+		return nil, err
+	}
+	if _, ok := local[stripFragment(uri)+"#"]; ok {
+		return lookupSchema(local, uri)
+	}
+	return r.Resolve(uri)
+}
+
+// indexSchema adds the schema resources and anchors of the document to the
+// index, the document being identified by uri unless it has an "$id".
+func indexSchema(index map[string]*Schema, s *Schema, uri string) error {
+	index[uri+"#"] = s
+	return indexResources(index, s, uri)
+}
+
+func indexResources(index map[string]*Schema, s *Schema, base string) error {
+	if s == nil || s.boolean != nil {
+		return nil
+	}
+	if s.ID != EmptyID {
+		id, err := resolveURI(base, s.ID.String())
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid $id %q: %w", s.ID, err)
+		}
+		base = stripFragment(id)
+		index[base+"#"] = s
+	}
+	if s.Anchor != "" {
+		index[base+"#"+s.Anchor] = s
+	}
+	if s.DynamicAnchor != "" {
+		index[base+"#"+s.DynamicAnchor] = s
+	}
+	for _, sub := range s.subschemas() {
+		if err := indexResources(index, sub, base); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupSchema finds the schema of an absolute URI in the index.
+func lookupSchema(index map[string]*Schema, uri string) (*Schema, error) {
+	base, fragment, _ := strings.Cut(uri, "#")
+	if fragment == "" || !strings.HasPrefix(fragment, "/") {
+		if s, ok := index[base+"#"+fragment]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("jsonschema: cannot resolve %q", uri)
+	}
+	root, ok := index[base+"#"]
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: cannot resolve %q", uri)
+	}
+	s, err := root.At(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: cannot resolve %q: %w", uri, err)
+	}
+	return s, nil
+}
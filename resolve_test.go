@@ -0,0 +1,213 @@
+package jsonschema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryResolve(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Add(mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/schemas/root",
+		"$defs": {
+			"a~b": {"type": "string"},
+			"item": {"$id": "item", "$anchor": "top", "$defs": {"x": {"$anchor": "deep", "type": "integer"}}},
+			"urn": {"$id": "urn:uuid:deadbeef-1234-ffff-ffff-4321feebdaed", "type": "null"}
+		},
+		"properties": {"list": {"prefixItems": [true, {"minimum": 1}]}}
+	}`)))
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"https://example.com/schemas/root#/$defs/a~0b", `{"type":"string"}`},
+		{"https://example.com/schemas/root#/properties/list/prefixItems/1", `{"minimum":1}`},
+		{"https://example.com/schemas/item#deep", `{"$anchor":"deep","type":"integer"}`},
+		{"https://example.com/schemas/item#/$defs/x", `{"$anchor":"deep","type":"integer"}`},
+		{"https://example.com/schemas/item#top", `{"$id":"item","$anchor":"top","$defs":{"x":{"$anchor":"deep","type":"integer"}}}`},
+		{"urn:uuid:deadbeef-1234-ffff-ffff-4321feebdaed", `{"$id":"urn:uuid:deadbeef-1234-ffff-ffff-4321feebdaed","type":"null"}`},
+	}
+	for _, tt := range tests {
+		s, err := r.Resolve(tt.uri)
+		require.NoError(t, err, tt.uri)
+		data, err := s.MarshalJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(data), tt.uri)
+	}
+
+	for _, uri := range []string{
+		"https://example.com/schemas/root#/$defs/missing",
+		"https://example.com/schemas/root#/properties/list/prefixItems/01",
+		"https://example.com/schemas/root#nothing",
+	} {
+		_, err := r.Resolve(uri)
+		assert.ErrorContains(t, err, "cannot resolve", uri)
+	}
+	_, err := r.Resolve("https://example.com/other")
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+
+	assert.ErrorContains(t, r.Add(&Schema{ID: "relative"}), "not an absolute URI")
+}
+
+func TestRegistryLoaders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"user.json":          {Data: []byte(`{"type":"object","properties":{"name":{"$ref":"common/name"}}}`)},
+		"common/name.json":   {Data: []byte(`{"$anchor":"name","type":"string"}`)},
+		"broken/schema.json": {Data: []byte(`{"type":`)},
+	}
+	failing := LoaderFunc(func(uri string) (*Schema, error) {
+		if uri == "https://example.com/fail" {
+			return nil, errors.New("boom")
+		}
+		return nil, ErrSchemaNotFound
+	})
+	r := NewRegistry(
+		MapLoader{"https://example.com/mem": mustUnmarshalSchema(t, `{"type":"boolean"}`)},
+		failing,
+		FSLoader(fsys, "https://example.com/schemas/"),
+	)
+
+	s, err := r.Resolve("https://example.com/mem")
+	require.NoError(t, err)
+	assert.Equal(t, "boolean", s.Type)
+
+	s, err = r.Resolve("https://example.com/schemas/user#/properties/name")
+	require.NoError(t, err)
+	assert.Equal(t, "common/name", s.Ref)
+
+	s, err = r.Resolve("https://example.com/schemas/common/name#name")
+	require.NoError(t, err)
+	assert.Equal(t, "string", s.Type)
+
+	_, err = r.Resolve("https://example.com/fail")
+	assert.EqualError(t, err, "boom")
+	_, err = r.Resolve("https://example.com/schemas/broken/schema.json")
+	assert.ErrorContains(t, err, "cannot decode")
+	_, err = r.Resolve("https://example.com/schemas/../secret")
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+
+	// a loader may use the registry itself, and a nil schema is not found
+	var reentrant *Registry
+	reentrant = NewRegistry(
+		LoaderFunc(func(uri string) (*Schema, error) { return nil, nil }),
+		LoaderFunc(func(uri string) (*Schema, error) {
+			if uri != "https://example.com/alias" {
+				return nil, ErrSchemaNotFound
+			}
+			return reentrant.Load("https://example.com/mem")
+		}),
+		r,
+	)
+	s, err = reentrant.Resolve("https://example.com/alias")
+	require.NoError(t, err)
+	assert.Equal(t, "boolean", s.Type)
+	_, err = reentrant.Resolve("https://example.com/none")
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestDirLoader(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pet.json"), []byte(`{"required":["name"]}`), 0o600))
+
+	v, err := Compile(mustUnmarshalSchema(t, `{"$ref":"https://example.com/schemas/pet"}`),
+		WithLoader(DirLoader(dir, "https://example.com/schemas/")))
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `{"name":"Rex"}`)))
+	assert.ErrorContains(t, v.Validate(mustDecodeInstance(t, `{}`)), `missing required properties ["name"]`)
+}
+
+func TestSchemaResolve(t *testing.T) {
+	require.NoError(t, DefaultRegistry.Add(mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/resolve-test/address",
+		"$defs": {"zip": {"$anchor": "zip", "pattern": "^[0-9]{5}$"}}
+	}`)))
+
+	s := mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/resolve-test/person",
+		"properties": {
+			"zip": {"$ref": "address#zip"},
+			"name": {"$ref": "#/$defs/name"}
+		},
+		"$defs": {"name": {"type": "string"}}
+	}`)
+
+	name, err := s.Resolve("#/$defs/name")
+	require.NoError(t, err)
+	assert.Equal(t, "string", name.Type)
+
+	self, err := s.Resolve("person")
+	require.NoError(t, err)
+	assert.Same(t, s, self)
+
+	zip, err := s.Resolve("address#zip")
+	require.NoError(t, err)
+	assert.Equal(t, "^[0-9]{5}$", zip.Pattern)
+
+	_, err = s.Resolve("#/$defs/missing")
+	assert.ErrorContains(t, err, `cannot resolve "https://example.com/resolve-test/person#/$defs/missing"`)
+
+	anonymous := mustUnmarshalSchema(t, `{"$defs":{"a":{"$anchor":"a","type":"integer"}}}`)
+	a, err := anonymous.Resolve("#a")
+	require.NoError(t, err)
+	assert.Equal(t, "integer", a.Type)
+}
+
+func TestRegistryResolveFrom(t *testing.T) {
+	r := NewRegistry(MapLoader{
+		"https://example.com/resolve-from/address": mustUnmarshalSchema(t, `{
+			"$defs": {"zip": {"$anchor": "zip", "pattern": "^[0-9]{5}$"}}
+		}`),
+	})
+	s := mustUnmarshalSchema(t, `{
+		"$id": "https://example.com/resolve-from/person",
+		"properties": {"zip": {"$ref": "address#zip"}},
+		"$defs": {"name": {"type": "string"}}
+	}`)
+
+	zip, err := r.ResolveFrom(s, "address#zip")
+	require.NoError(t, err)
+	assert.Equal(t, "^[0-9]{5}$", zip.Pattern)
+
+	name, err := r.ResolveFrom(s, "#/$defs/name")
+	require.NoError(t, err)
+	assert.Equal(t, "string", name.Type)
+
+	_, err = s.Resolve("address#zip")
+	assert.ErrorIs(t, err, ErrSchemaNotFound, "Schema.Resolve only uses DefaultRegistry")
+}
+
+func TestCompileWithLoaderResolvesLookupReferences(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type Person struct {
+		Address Address `json:"address"`
+	}
+
+	lookup := func(t reflect.Type) ID {
+		if t.Kind() != reflect.Struct {
+			return EmptyID
+		}
+		return ID("https://example.com/schemas/" + t.Name())
+	}
+	r := &Reflector{Lookup: lookup, DoNotReference: true}
+	person := r.Reflect(&Person{})
+	address := r.Reflect(&Address{})
+	require.Equal(t, "https://example.com/schemas/Address", person.Properties.values["address"].Ref)
+
+	_, err := Compile(person)
+	assert.ErrorContains(t, err, `cannot resolve $ref "https://example.com/schemas/Address"`)
+
+	v, err := Compile(person, WithLoader(MapLoader{address.ID: address}))
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(&Person{Address: Address{City: "Paris"}}))
+	assert.ErrorContains(t, v.Validate(map[string]any{"address": map[string]any{"city": 1}}), "/address/city: expected string but got integer")
+}
@@ -11,13 +11,15 @@ var Version = "https://json-schema.org/draft/2020-12/schema"
 // RFC draft-bhutton-json-schema-00 section 4.3
 type Schema struct {
 	// RFC draft-bhutton-json-schema-00
-	Version     string      `json:"$schema,omitzero,omitempty"`     // section 8.1.1
-	ID          ID          `json:"$id,omitzero,omitempty"`         // section 8.2.1
-	Anchor      string      `json:"$anchor,omitzero,omitempty"`     // section 8.2.2
-	Ref         string      `json:"$ref,omitzero,omitempty"`        // section 8.2.3.1
-	DynamicRef  string      `json:"$dynamicRef,omitzero,omitempty"` // section 8.2.3.2
-	Definitions Definitions `json:"$defs,omitzero,omitempty"`       // section 8.2.4
-	Comments    string      `json:"$comment,omitzero,omitempty"`    // section 8.3
+	Version       string          `json:"$schema,omitzero,omitempty"`        // section 8.1.1
+	Vocabulary    map[string]bool `json:"$vocabulary,omitzero,omitempty"`    // section 8.1.2
+	ID            ID              `json:"$id,omitzero,omitempty"`            // section 8.2.1
+	Anchor        string          `json:"$anchor,omitzero,omitempty"`        // section 8.2.2
+	DynamicAnchor string          `json:"$dynamicAnchor,omitzero,omitempty"` // section 8.2.2
+	Ref           string          `json:"$ref,omitzero,omitempty"`           // section 8.2.3.1
+	DynamicRef    string          `json:"$dynamicRef,omitzero,omitempty"`    // section 8.2.3.2
+	Definitions   Definitions     `json:"$defs,omitzero,omitempty"`          // section 8.2.4
+	Comments      string          `json:"$comment,omitzero,omitempty"`       // section 8.3
 	// RFC draft-bhutton-json-schema-00 section 10.2.1 (Sub-schemas with logic)
 	AllOf []*Schema `json:"allOf,omitzero,omitempty"` // section 10.2.1.1
 	AnyOf []*Schema `json:"anyOf,omitzero,omitempty"` // section 10.2.1.2
@@ -37,6 +39,9 @@ type Schema struct {
 	PatternProperties    map[string]*Schema `json:"patternProperties,omitzero,omitempty"`    // section 10.3.2.2
 	AdditionalProperties *Schema            `json:"additionalProperties,omitzero,omitempty"` // section 10.3.2.3
 	PropertyNames        *Schema            `json:"propertyNames,omitzero,omitempty"`        // section 10.3.2.4
+	// RFC draft-bhutton-json-schema-00 section 11 (unevaluated locations)
+	UnevaluatedItems      *Schema `json:"unevaluatedItems,omitzero,omitempty"`      // section 11.2
+	UnevaluatedProperties *Schema `json:"unevaluatedProperties,omitzero,omitempty"` // section 11.3
 
 	// Type is the instance data model type (RFC draft-bhutton-json-schema-validation-00, section 6).
 	// The keyword in JSON Schema is "type".
@@ -52,7 +57,7 @@ type Schema struct {
 	TypeEnhanced []string `json:"-"` // section 6.1.1
 
 	Enum              []any               `json:"enum,omitzero,omitempty"`              // section 6.1.2
-	Const             any                 `json:"const,omitzero,omitempty"`             // section 6.1.3
+	Const             any                 `json:"const,omitzero,omitempty"`             // section 6.1.3, Null for null
 	MultipleOf        jsonv1.Number       `json:"multipleOf,omitzero,omitempty"`        // section 6.2.1
 	Maximum           jsonv1.Number       `json:"maximum,omitzero,omitempty"`           // section 6.2.2
 	ExclusiveMaximum  jsonv1.Number       `json:"exclusiveMaximum,omitzero,omitempty"`  // section 6.2.3
@@ -85,6 +90,9 @@ type Schema struct {
 	WriteOnly   bool   `json:"writeOnly,omitzero,omitempty"`   // section 9.4
 	Examples    []any  `json:"examples,omitzero,omitempty"`    // section 9.5
 
+	// Extras holds the keywords that are not defined by the specification.
+	// They are set by the jsonschema_extras struct tag, kept when unmarshalling
+	// a schema and applied by validation when registered with RegisterKeyword.
 	Extras map[string]any `json:"-"`
 
 	// Special boolean representation of the Schema - section 4.3.2
@@ -103,6 +111,17 @@ var (
 	FalseSchema = &Schema{boolean: &[]bool{false}[0]}
 )
 
+// Null is the value of Const for "const": null, a nil Const meaning that the
+// schema has no "const" keyword.
+var Null any = jsonNull{}
+
+type jsonNull struct{}
+
+// MarshalJSON returns null.
+func (jsonNull) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
 // Definitions hold schema definitions.
 // http://json-schema.org/latest/json-schema-validation.html#rfc.section.5.26
 // RFC draft-wright-json-schema-validation-00, section 5.26
@@ -0,0 +1,162 @@
+package jsonschema
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	suiteDir           = "testdata/JSON-Schema-Test-Suite/tests/draft2020-12"
+	suiteRemotesDir    = "testdata/JSON-Schema-Test-Suite/remotes"
+	suiteKnownFailures = "testdata/JSON-Schema-Test-Suite/known_failures.txt"
+
+	// suiteRemotesBase is the URI the upstream suite serves its remotes
+	// directory from.
+	suiteRemotesBase = "http://localhost:1234/"
+)
+
+// suiteGroup is a single schema of a JSON-Schema-Test-Suite file together with
+// the instances it is tested against.
+type suiteGroup struct {
+	Description string         `json:"description"`
+	Schema      jsontext.Value `json:"schema"`
+	Tests       []struct {
+		Description string         `json:"description"`
+		Data        jsontext.Value `json:"data"`
+		Valid       bool           `json:"valid"`
+	} `json:"tests"`
+}
+
+// TestSuite runs the vendored draft 2020-12 cases of the JSON-Schema-Test-Suite.
+// Cases listed in the known failures file are allowed to fail, every other
+// failure is reported, and so is a known failure that started to pass. Run the
+// tests with -update to rewrite the list after fixing or breaking a case.
+func TestSuite(t *testing.T) {
+	known, err := readKnownFailures(suiteKnownFailures)
+	require.NoError(t, err)
+
+	var files []string
+	err = filepath.WalkDir(suiteDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".json" {
+			files = append(files, path)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "no test suite files in %s", suiteDir)
+
+	var failures []string
+	for _, path := range files {
+		name := filepath.ToSlash(strings.TrimPrefix(path, suiteDir+string(filepath.Separator)))
+		t.Run(strings.TrimSuffix(name, ".json"), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var groups []suiteGroup
+			require.NoError(t, json.Unmarshal(data, &groups))
+
+			opts := []CompileOption{WithLoader(MetaSchemaLoader, DirLoader(suiteRemotesDir, suiteRemotesBase))}
+			if strings.HasPrefix(name, "optional/format/") {
+				opts = append(opts, WithFormatAssertion())
+			}
+
+			passed, total := 0, 0
+			for _, g := range groups {
+				v, compileErr := compileSuiteSchema(g.Schema, opts...)
+				for _, tc := range g.Tests {
+					total++
+					id := name + " :: " + g.Description + " :: " + tc.Description
+					reason := compileErr
+					if reason == nil {
+						reason = runSuiteCase(v, tc.Data, tc.Valid)
+					}
+					switch {
+					case reason == nil && known[id]:
+						passed++
+						if !*updateFixtures {
+							t.Errorf("%s: known failure now passes, remove it from %s", id, suiteKnownFailures)
+						}
+					case reason == nil:
+						passed++
+					case known[id]:
+						failures = append(failures, id)
+					default:
+						failures = append(failures, id)
+						if !*updateFixtures {
+							t.Errorf("%s: %v", id, reason)
+						}
+					}
+				}
+			}
+			t.Logf("%s: %d/%d passed", name, passed, total)
+		})
+	}
+
+	if *updateFixtures {
+		require.NoError(t, writeKnownFailures(suiteKnownFailures, failures))
+	}
+}
+
+func compileSuiteSchema(data jsontext.Value, opts ...CompileOption) (*Validator, error) {
+	s := new(Schema)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal schema: %w", err)
+	}
+	return Compile(s, opts...)
+}
+
+func runSuiteCase(v *Validator, data jsontext.Value, valid bool) error {
+	inst, err := decodeInstance(data)
+	if err != nil {
+		return err
+	}
+	err = v.Validate(inst)
+	switch {
+	case valid && err != nil:
+		return fmt.Errorf("expected valid instance %s: %w", data, err)
+	case !valid && err == nil:
+		return fmt.Errorf("expected invalid instance %s", data)
+	}
+	return nil
+}
+
+func readKnownFailures(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	known := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		known[line] = true
+	}
+	return known, sc.Err()
+}
+
+func writeKnownFailures(path string, ids []string) error {
+	slices.Sort(ids)
+	var buf bytes.Buffer
+	buf.WriteString("# Known failures of the JSON-Schema-Test-Suite, one \"file :: schema :: test\" per line.\n")
+	buf.WriteString("# Regenerate with: go test -run TestSuite -update\n")
+	for _, id := range ids {
+		buf.WriteString(id)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
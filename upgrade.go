@@ -0,0 +1,403 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	json "encoding/json/v2"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// parseDialect returns the dialect identified by a "$schema" URI, accepting
+// it with or without an empty fragment and with either http or https.
+func parseDialect(uri string) (Dialect, bool) {
+	norm := func(s string) string {
+		s = strings.TrimSuffix(s, "#")
+		s = strings.TrimPrefix(s, "http://")
+		return strings.TrimPrefix(s, "https://")
+	}
+	for _, n := range dialectNames {
+		if norm(uri) == norm(string(n.dialect)) {
+			return n.dialect, true
+		}
+	}
+	return "", false
+}
+
+// documentDialect returns the "$schema" of a schema object, empty when it
+// has none, and the dialect it identifies, empty when unknown.
+func documentDialect(data []byte) (string, Dialect) {
+	if !bytes.Contains(data, []byte(`"$schema"`)) {
+		return "", ""
+	}
+	var doc struct {
+		Schema *string `json:"$schema"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Schema == nil {
+		return "", ""
+	}
+	d, _ := parseDialect(*doc.Schema)
+	return *doc.Schema, d
+}
+
+// Upgrade parses a schema document written for draft-04, draft-06, draft-07
+// or 2020-12, as told by its "$schema", into the 2020-12 model. The dialect d
+// is assumed for documents without "$schema", 2020-12 when empty. The
+// keywords of the older dialects are mapped onto their 2020-12 equivalents:
+//
+//   - "definitions" to "$defs", and "dependencies" to "dependentRequired"
+//     or "dependentSchemas";
+//   - the array form of "items" to "prefixItems", and "additionalItems" to
+//     "items";
+//   - "$id" with a fragment to "$anchor", and the draft-04 "id" to "$id";
+//   - the draft-04 boolean "exclusiveMinimum" and "exclusiveMaximum" to the
+//     numeric form;
+//   - the JSON Pointers of "$ref" to the renamed keywords.
+//
+// As older dialects ignore the keywords next to "$ref", they are dropped
+// with a warning, except for the identifiers and definitions. The "$schema"
+// of the result is the 2020-12 one. Schemas whose "$schema" names another
+// meta-schema are left as they are, with a warning.
+//
+// Schema.UnmarshalJSON does not upgrade documents: it reads the 2020-12
+// keywords whatever their "$schema".
+func Upgrade(data []byte, d Dialect) (*Schema, []Warning, error) {
+	var warnings []Warning
+	if uri, declared := documentDialect(data); uri != "" {
+		d = declared
+		if d == "" {
+			d = Draft202012
+			warnings = append(warnings, unknownDialect(""))
+		}
+	}
+	if d == "" {
+		d = Draft202012
+	}
+	if d.rank() < 0 {
+		return nil, nil, fmt.Errorf("jsonschema: unsupported dialect %q", d)
+	}
+	if d != Draft202012 {
+		var err error
+		if data, warnings, err = upgradeSchema(data, d); err != nil {
+			return nil, nil, err
+		}
+	}
+	s := new(Schema)
+	if err := s.UnmarshalJSON(data); err != nil {
+		return nil, nil, err
+	}
+	return s, warnings, nil
+}
+
+// unknownDialect is the warning for a schema resource whose "$schema" is not
+// one of the supported dialects, and which is not upgraded.
+func unknownDialect(ptr string) Warning {
+	return Warning{Location: ptr, Keyword: "$schema", Message: "unknown dialect, not upgraded"}
+}
+
+// upgradeSchema rewrites a schema document of an older dialect with the
+// 2020-12 keywords.
+func upgradeSchema(data []byte, d Dialect) ([]byte, []Warning, error) {
+	u := &upgrader{dialect: d}
+	v, err := u.schema(jsontext.Value(data), "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("jsonschema: cannot upgrade %s schema: %w", d.name(), err)
+	}
+	return v, u.warnings, nil
+}
+
+type upgrader struct {
+	dialect  Dialect
+	warnings []Warning
+}
+
+// jsonMember is a member of a JSON object, kept in document order.
+type jsonMember struct {
+	name  string
+	value jsontext.Value
+}
+
+// refSiblings are the keywords kept next to "$ref" when upgrading, as they
+// locate or identify schemas rather than apply to the instance.
+var refSiblings = []string{"$ref", "$schema", "$id", "id", "$comment", "definitions", "$defs"}
+
+// schemaListKeywords are the keywords taking a list of schemas, and
+// schemaMapKeywords the ones taking an object of schemas.
+var (
+	schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	schemaMapKeywords  = []string{"properties", "patternProperties", "$defs", "dependentSchemas"}
+)
+
+// schema upgrades the schema at the pointer of the document.
+func (u *upgrader) schema(v jsontext.Value, ptr string) (jsontext.Value, error) {
+	members, err := decodeMembers(v)
+	if err != nil || members == nil {
+		return v, err
+	}
+	member := func(name string) (jsontext.Value, bool) {
+		i := slices.IndexFunc(members, func(m jsonMember) bool { return m.name == name })
+		if i < 0 {
+			return nil, false
+		}
+		return members[i].value, true
+	}
+	if value, ok := member("$schema"); ok && ptr != "" {
+		var uri string
+		_ = json.Unmarshal(value, &uri)
+		d, ok := parseDialect(uri)
+		if !ok {
+			u.warnings = append(u.warnings, unknownDialect(ptr))
+			return v, nil
+		}
+		if d != u.dialect {
+			// embedded schema resource of another dialect
+			if d == Draft202012 {
+				return v, nil
+			}
+			defer func(prev Dialect) { u.dialect = prev }(u.dialect)
+			u.dialect = d
+		}
+	}
+	_, hasRef := member("$ref")
+	items, _ := member("items")
+	itemsArray := items.Kind() == '['
+	draft04 := u.dialect == Draft04
+	exclusive := func(name string) bool {
+		v, ok := member(name)
+		return draft04 && ok && v.Kind() == 't'
+	}
+
+	var out []jsonMember
+	for _, m := range members {
+		at := ptr + "/" + escapePointerToken(m.name)
+		if hasRef && !slices.Contains(refSiblings, m.name) {
+			u.warnings = append(u.warnings, Warning{
+				Location: ptr,
+				Keyword:  m.name,
+				Message:  fmt.Sprintf("ignored next to $ref in %s, dropped", u.dialect.name()),
+			})
+			continue
+		}
+		switch {
+		case m.name == "$schema":
+			out = append(out, jsonMember{m.name, mustString(string(Draft202012))})
+		case m.name == "$id" && !draft04, m.name == "id" && draft04:
+			var id string
+			if err := json.Unmarshal(m.value, &id); err != nil {
+				return nil, fmt.Errorf("invalid %s at %q: %w", m.name, at, err)
+			}
+			base, anchor, _ := strings.Cut(id, "#")
+			if base != "" {
+				out = append(out, jsonMember{"$id", mustString(base)})
+			}
+			switch {
+			case anchor == "":
+			case strings.HasPrefix(anchor, "/"):
+				u.warnings = append(u.warnings, Warning{Location: ptr, Keyword: m.name, Message: "JSON Pointer fragments are not allowed in $id, dropped"})
+			default:
+				out = append(out, jsonMember{"$anchor", mustString(anchor)})
+			}
+		case m.name == "definitions":
+			if _, ok := member("$defs"); ok {
+				u.warnings = append(u.warnings, Warning{Location: ptr, Keyword: m.name, Message: "conflicts with $defs, dropped"})
+				continue
+			}
+			value, err := u.schemaMap(m.value, at)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonMember{"$defs", value})
+		case m.name == "dependencies":
+			deps, err := decodeMembers(m.value)
+			if err != nil {
+				return nil, err
+			}
+			var required, schemas []jsonMember
+			for _, dep := range deps {
+				if dep.value.Kind() == '[' {
+					required = append(required, dep)
+					continue
+				}
+				value, err := u.schema(dep.value, at+"/"+escapePointerToken(dep.name))
+				if err != nil {
+					return nil, err
+				}
+				schemas = append(schemas, jsonMember{dep.name, value})
+			}
+			if required != nil {
+				out = append(out, jsonMember{"dependentRequired", encodeMembers(required)})
+			}
+			if schemas != nil {
+				out = append(out, jsonMember{"dependentSchemas", encodeMembers(schemas)})
+			}
+		case m.name == "items" && itemsArray:
+			value, err := u.schemaList(m.value, at)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonMember{"prefixItems", value})
+		case m.name == "additionalItems":
+			if !itemsArray {
+				continue // no effect without the array form of items
+			}
+			value, err := u.schema(m.value, at)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonMember{"items", value})
+		case draft04 && (m.name == "exclusiveMinimum" || m.name == "exclusiveMaximum"):
+			bound, ok := member(strings.ToLower(strings.TrimPrefix(m.name, "exclusive")))
+			if m.value.Kind() == 't' && ok {
+				out = append(out, jsonMember{m.name, bound})
+			}
+		case m.name == "minimum" && exclusive("exclusiveMinimum"), m.name == "maximum" && exclusive("exclusiveMaximum"):
+			continue
+		case m.name == "$ref":
+			var ref string
+			if err := json.Unmarshal(m.value, &ref); err != nil {
+				return nil, fmt.Errorf("invalid $ref at %q: %w", at, err)
+			}
+			out = append(out, jsonMember{m.name, mustString(upgradeRef(ref))})
+		case isSingleKeyword(m.name):
+			value, err := u.schema(m.value, at)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonMember{m.name, value})
+		case slices.Contains(schemaListKeywords, m.name):
+			value, err := u.schemaList(m.value, at)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonMember{m.name, value})
+		case slices.Contains(schemaMapKeywords, m.name):
+			value, err := u.schemaMap(m.value, at)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonMember{m.name, value})
+		default:
+			out = append(out, m)
+		}
+	}
+	return encodeMembers(out), nil
+}
+
+func isSingleKeyword(name string) bool {
+	for _, kw := range singleKeywords {
+		if kw.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaList upgrades a list of schemas.
+func (u *upgrader) schemaList(v jsontext.Value, ptr string) (jsontext.Value, error) {
+	var list []jsontext.Value
+	if err := json.Unmarshal(v, &list); err != nil {
+		return v, nil
+	}
+	for i, item := range list {
+		value, err := u.schema(item, fmt.Sprintf("%s/%d", ptr, i))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = value
+	}
+	data, err := json.Marshal(list)
+	return jsontext.Value(data), err
+}
+
+// schemaMap upgrades an object of schemas.
+func (u *upgrader) schemaMap(v jsontext.Value, ptr string) (jsontext.Value, error) {
+	members, err := decodeMembers(v)
+	if err != nil || members == nil {
+		return v, err
+	}
+	for i, m := range members {
+		value, err := u.schema(m.value, ptr+"/"+escapePointerToken(m.name))
+		if err != nil {
+			return nil, err
+		}
+		members[i].value = value
+	}
+	return encodeMembers(members), nil
+}
+
+// upgradeRef rewrites the JSON Pointer of a reference for the renamed
+// keywords.
+func upgradeRef(ref string) string {
+	uri, fragment, ok := strings.Cut(ref, "#")
+	if !ok || !strings.HasPrefix(fragment, "/") {
+		return ref
+	}
+	tokens := strings.Split(fragment[1:], "/")
+	for i := 0; i < len(tokens); {
+		hasNext := i+1 < len(tokens)
+		consumed := 1
+		switch tokens[i] {
+		case "definitions":
+			tokens[i], consumed = "$defs", 2
+		case "dependencies":
+			tokens[i], consumed = "dependentSchemas", 2
+		case "additionalItems":
+			tokens[i] = "items"
+		case "items":
+			if hasNext {
+				if _, ok := arrayIndex(tokens[i+1]); ok {
+					tokens[i], consumed = "prefixItems", 2
+				}
+			}
+		default:
+			consumed = childTokens(tokens[i], hasNext)
+		}
+		i += consumed
+	}
+	return uri + "#/" + strings.Join(tokens, "/")
+}
+
+// decodeMembers returns the members of a JSON object in document order, or
+// nil when the value is not an object.
+func decodeMembers(v jsontext.Value) ([]jsonMember, error) {
+	if v.Kind() != '{' {
+		return nil, nil
+	}
+	dec := jsontext.NewDecoder(bytes.NewReader(v))
+	if _, err := dec.ReadToken(); err != nil {
+		return nil, err
+	}
+	members := []jsonMember{}
+	for dec.PeekKind() != '}' {
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+		name := tok.String()
+		value, err := dec.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{name, slices.Clone(value)})
+	}
+	return members, nil
+}
+
+// encodeMembers returns the JSON object with the members.
+func encodeMembers(members []jsonMember) jsontext.Value {
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	_ = enc.WriteToken(jsontext.BeginObject)
+	for _, m := range members {
+		_ = enc.WriteToken(jsontext.String(m.name))
+		_ = enc.WriteValue(m.value)
+	}
+	_ = enc.WriteToken(jsontext.EndObject)
+	return jsontext.Value(bytes.TrimSpace(buf.Bytes()))
+}
+
+func mustString(s string) jsontext.Value {
+	v, _ := jsontext.AppendQuote(nil, s)
+	return jsontext.Value(v)
+}
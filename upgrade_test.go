@@ -0,0 +1,172 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeDraft07(t *testing.T) {
+	s, warnings, err := Upgrade([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"$id": "https://example.com/order",
+		"type": "object",
+		"properties": {
+			"line": {"$ref": "#/definitions/line", "description": "ignored"},
+			"rest": {"$ref": "#/definitions/line/additionalItems"},
+			"first": {"$ref": "#/definitions/line/items/0"},
+			"card": {"$ref": "#/dependencies/card"},
+			"note": {"$id": "#note", "type": "string"}
+		},
+		"dependencies": {
+			"card": {"required": ["billing"]},
+			"coupon": ["code"]
+		},
+		"definitions": {
+			"line": {
+				"items": [{"type": "string"}, {"type": "integer"}],
+				"additionalItems": false
+			},
+			"list": {"items": {"type": "string"}, "additionalItems": false}
+		}
+	}`), "")
+	require.NoError(t, err)
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/order",
+		"type": "object",
+		"properties": {
+			"line": {"$ref": "#/$defs/line"},
+			"rest": {"$ref": "#/$defs/line/items"},
+			"first": {"$ref": "#/$defs/line/prefixItems/0"},
+			"card": {"$ref": "#/dependentSchemas/card"},
+			"note": {"$anchor": "note", "type": "string"}
+		},
+		"dependentRequired": {"coupon": ["code"]},
+		"dependentSchemas": {"card": {"required": ["billing"]}},
+		"$defs": {
+			"line": {
+				"prefixItems": [{"type": "string"}, {"type": "integer"}],
+				"items": false
+			},
+			"list": {"items": {"type": "string"}}
+		}
+	}`, string(data))
+	assert.Equal(t, []Warning{
+		{Location: "/properties/line", Keyword: "description", Message: "ignored next to $ref in draft-07, dropped"},
+	}, warnings)
+
+	_, err = Compile(s)
+	require.NoError(t, err)
+}
+
+func TestUpgradeDraft04(t *testing.T) {
+	s, warnings, err := Upgrade([]byte(`{
+		"id": "https://example.com/price#root",
+		"type": "object",
+		"properties": {
+			"amount": {"type": "number", "minimum": 0, "exclusiveMinimum": true, "maximum": 100, "exclusiveMaximum": false},
+			"ratio": {"maximum": 1, "exclusiveMaximum": true},
+			"self": {"id": "#/bad", "$ref": "#"}
+		}
+	}`), Draft04)
+	require.NoError(t, err)
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$id": "https://example.com/price",
+		"$anchor": "root",
+		"type": "object",
+		"properties": {
+			"amount": {"type": "number", "exclusiveMinimum": 0, "maximum": 100},
+			"ratio": {"exclusiveMaximum": 1},
+			"self": {"$ref": "#"}
+		}
+	}`, string(data))
+	assert.Equal(t, []Warning{
+		{Location: "/properties/self", Keyword: "id", Message: "JSON Pointer fragments are not allowed in $id, dropped"},
+	}, warnings)
+}
+
+func TestUpgradeEmbeddedDialect(t *testing.T) {
+	s, _, err := Upgrade([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema",
+		"items": [{"type": "string"}],
+		"definitions": {
+			"modern": {
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "https://example.com/modern",
+				"definitions": {"name": {"type": "string"}}
+			}
+		}
+	}`), "")
+	require.NoError(t, err)
+	require.Len(t, s.PrefixItems, 1)
+	require.Contains(t, s.Definitions, "modern")
+	assert.Nil(t, s.Definitions["modern"].Definitions)
+	assert.Contains(t, s.Definitions["modern"].Extras, "definitions")
+}
+
+func TestUpgradeCompile(t *testing.T) {
+	s, warnings, err := Upgrade([]byte(`{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"definitions": {"positive": {"minimum": 0, "exclusiveMinimum": true}},
+		"dependencies": {"a": ["b"]},
+		"items": [{"$ref": "#/definitions/positive"}],
+		"additionalItems": {"type": "string"}
+	}`), "")
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, string(Draft202012), s.Version)
+	require.Contains(t, s.Definitions, "positive")
+	assert.EqualValues(t, "0", s.Definitions["positive"].ExclusiveMinimum)
+	assert.Equal(t, map[string][]string{"a": {"b"}}, s.DependentRequired)
+	require.Len(t, s.PrefixItems, 1)
+	assert.Equal(t, "#/$defs/positive", s.PrefixItems[0].Ref)
+	require.NotNil(t, s.Items)
+	assert.Equal(t, "string", s.Items.Type)
+
+	v, err := Compile(s)
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(mustDecodeInstance(t, `[1, "x"]`)))
+	assert.Error(t, v.Validate(mustDecodeInstance(t, `[0]`)))
+	assert.Error(t, v.Validate(mustDecodeInstance(t, `{"a": 1}`)))
+}
+
+func TestUnmarshalOlderDialect(t *testing.T) {
+	// documents are only upgraded by Upgrade
+	for _, doc := range []string{
+		`{"$schema": "http://json-schema.org/draft-07/schema#", "definitions": {"a": {}}}`,
+		`{"$schema": "http://json-schema.org/draft-07/schema#", "$ref": "#/definitions/a", "description": "A", "definitions": {"a": {}}}`,
+		`{"$schema": "https://json-schema.org/draft/2019-09/schema", "definitions": {"a": {}}}`,
+		`{"$schema": "http://json-schema.org/schema#", "definitions": {"a": {}}}`,
+	} {
+		s := mustUnmarshalSchema(t, doc)
+		assert.NotEqual(t, string(Draft202012), s.Version, doc)
+		assert.Nil(t, s.Definitions, doc)
+		assert.Contains(t, s.Extras, "definitions", doc)
+	}
+}
+
+func TestUpgradeWarnings(t *testing.T) {
+	_, warnings, err := Upgrade([]byte(`{"$schema": "http://json-schema.org/draft-07/schema#", "$ref": "#/definitions/a", "type": "string", "definitions": {"a": {}}}`), "")
+	require.NoError(t, err)
+	assert.Equal(t, []Warning{{Location: "", Keyword: "type", Message: "ignored next to $ref in draft-07, dropped"}}, warnings)
+
+	// schemas of unknown dialects are left as they are
+	s, warnings, err := Upgrade([]byte(`{"$schema": "https://json-schema.org/draft/2019-09/schema", "definitions": {"a": {}}}`), Draft07)
+	require.NoError(t, err)
+	assert.Equal(t, "https://json-schema.org/draft/2019-09/schema", s.Version)
+	assert.Nil(t, s.Definitions)
+	assert.Equal(t, []Warning{{Location: "", Keyword: "$schema", Message: "unknown dialect, not upgraded"}}, warnings)
+
+	s, warnings, err = Upgrade([]byte(`{"definitions": {"a": {"$schema": "https://example.com/meta", "definitions": {}}}}`), Draft07)
+	require.NoError(t, err)
+	require.Contains(t, s.Definitions, "a")
+	assert.Equal(t, "https://example.com/meta", s.Definitions["a"].Version)
+	assert.Contains(t, s.Definitions["a"].Extras, "definitions")
+	assert.Equal(t, []Warning{{Location: "/definitions/a", Keyword: "$schema", Message: "unknown dialect, not upgraded"}}, warnings)
+}
@@ -0,0 +1,987 @@
+package jsonschema
+
+import (
+	"bytes"
+	json "encoding/json/v2"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	jsonv1 "github.com/goccy/go-json"
+)
+
+// ValidationError describes why an instance failed validation against a
+// schema. Errors form a tree: the root error refers to the whole instance and
+// each of its Causes refers to a keyword (or sub-schema) that failed.
+type ValidationError struct {
+	// KeywordLocation is the JSON Pointer to the failing keyword, relative to
+	// the root schema and following any "$ref" that was crossed on the way.
+	KeywordLocation string
+	// AbsoluteKeywordLocation is the absolute URI of the failing keyword,
+	// built from the "$id" of the schema resource it belongs to. It is empty
+	// when the schema has no "$id".
+	AbsoluteKeywordLocation string
+	// InstanceLocation is the JSON Pointer to the failing part of the instance.
+	InstanceLocation string
+	// FieldPath is the Go expression of the failing field, such as
+	// `User.Friends[2]`. It is only set by Reflector.ValidateValue.
+	FieldPath string
+	// Message is a human readable description of the failure.
+	Message string
+	// Causes are the nested errors that led to this one.
+	Causes []*ValidationError
+}
+
+func newValidationError(u *OutputUnit) *ValidationError {
+	e := &ValidationError{
+		KeywordLocation:         u.KeywordLocation,
+		AbsoluteKeywordLocation: u.AbsoluteKeywordLocation,
+		InstanceLocation:        u.InstanceLocation,
+		Message:                 u.Error,
+	}
+	if len(u.Errors) > 0 {
+		e.Causes = make([]*ValidationError, len(u.Errors))
+		for i, c := range u.Errors {
+			e.Causes[i] = newValidationError(c)
+		}
+	}
+	return e
+}
+
+// Error implements the error interface by listing every leaf cause.
+func (e *ValidationError) Error() string {
+	leaves := e.leaves(nil)
+	if len(leaves) == 1 {
+		return "jsonschema: " + leaves[0].describe()
+	}
+	var b strings.Builder
+	b.WriteString("jsonschema: validation failed:")
+	for _, l := range leaves {
+		b.WriteString("\n- ")
+		b.WriteString(l.describe())
+	}
+	return b.String()
+}
+
+func (e *ValidationError) describe() string {
+	loc := e.InstanceLocation
+	if loc == "" {
+		loc = "(root)"
+	}
+	if e.FieldPath != "" {
+		loc += " (" + e.FieldPath + ")"
+	}
+	return fmt.Sprintf("%s: %s (at %q)", loc, e.Message, e.KeywordLocation)
+}
+
+func (e *ValidationError) leaves(dst []*ValidationError) []*ValidationError {
+	if len(e.Causes) == 0 {
+		return append(dst, e)
+	}
+	for _, c := range e.Causes {
+		dst = c.leaves(dst)
+	}
+	return dst
+}
+
+// Validate evaluates the instance against the schema following the JSON Schema
+// Draft 2020-12 validation rules. The instance may be any value produced by
+// decoding JSON into an `any` (maps, slices, strings, numbers, booleans and
+// nil), or a Go value that will first be encoded to JSON.
+//
+// The schema is compiled on every call; use Compile to validate many
+// instances against the same schema.
+//
+// A nil error means the instance is valid; otherwise a *ValidationError is
+// returned.
+func (t *Schema) Validate(instance any) error {
+	v, err := Compile(t)
+	if err != nil {
+		return err
+	}
+	return v.Validate(instance)
+}
+
+// Validate evaluates the instance against the compiled schema, see
+// Schema.Validate for the accepted instance values.
+func (v *Validator) Validate(instance any) error {
+	inst, err := normalizeInstance(instance)
+	if err != nil {
+		return err
+	}
+	st := &evalState{track: v.track, dynamic: v.dynamic}
+	if u := st.eval(v.root, inst, "", ""); !u.valid() {
+		return newValidationError(u)
+	}
+	return nil
+}
+
+// evalState holds the state of a single validation, keeping the Validator
+// itself free of any mutable data.
+type evalState struct {
+	refs []refFrame
+
+	// annotate collects annotations and the results of passing keywords,
+	// verbose additionally keeps every passing sub-schema.
+	annotate bool
+	verbose  bool
+
+	// track records the properties and items evaluated by each schema.
+	track bool
+
+	// dynamic is the index of dynamic anchors of the Validator and scope the
+	// base URIs of the schema resources entered so far, outermost first. The
+	// scope is only maintained when dynamic is set.
+	dynamic map[string]*schemaNode
+	scope   []string
+}
+
+// refFrame records a reference being followed in order to detect references
+// that loop without moving through the instance.
+type refFrame struct {
+	node     *schemaNode
+	instance string
+}
+
+// evalFrame collects the results of the keywords of a single schema.
+type evalFrame struct {
+	st          *evalState
+	node        *schemaNode
+	kwLoc       string
+	instLoc     string
+	errors      []*OutputUnit
+	annotations []*OutputUnit
+	seen        *evaluated
+}
+
+// evaluated records the properties and items of an instance evaluated by a
+// schema and its in-place applicators, as needed by "unevaluatedProperties"
+// and "unevaluatedItems". It is nil when nothing needs to be tracked, in
+// which case its methods do nothing.
+//
+// RFC draft-bhutton-json-schema-00 section 11
+type evaluated struct {
+	props    map[string]bool
+	items    int
+	allItems bool
+	indexes  map[int]bool
+}
+
+func (e *evaluated) addProps(names []string) {
+	if e == nil || len(names) == 0 {
+		return
+	}
+	if e.props == nil {
+		e.props = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		e.props[name] = true
+	}
+}
+
+func (e *evaluated) addIndexes(indexes []int) {
+	if e == nil || len(indexes) == 0 {
+		return
+	}
+	if e.indexes == nil {
+		e.indexes = make(map[int]bool, len(indexes))
+	}
+	for _, i := range indexes {
+		e.indexes[i] = true
+	}
+}
+
+func (e *evaluated) addItems(n int, all bool) {
+	if e == nil {
+		return
+	}
+	e.items = max(e.items, n)
+	e.allItems = e.allItems || all
+}
+
+func (e *evaluated) hasItem(i int) bool {
+	return e.allItems || i < e.items || e.indexes[i]
+}
+
+func (e *evaluated) merge(o *evaluated) {
+	if e == nil || o == nil {
+		return
+	}
+	for name := range o.props {
+		e.addProps([]string{name})
+	}
+	e.items = max(e.items, o.items)
+	e.allItems = e.allItems || o.allItems
+	for i := range o.indexes {
+		e.addIndexes([]int{i})
+	}
+}
+
+func (f *evalFrame) fail(keyword, format string, args ...any) {
+	f.errors = append(f.errors, &OutputUnit{
+		KeywordLocation:         f.kwLoc + "/" + keyword,
+		AbsoluteKeywordLocation: f.node.keywordURI(keyword),
+		InstanceLocation:        f.instLoc,
+		Error:                   fmt.Sprintf(format, args...),
+	})
+}
+
+// failWith reports a keyword that failed because of its sub-schemas.
+func (f *evalFrame) failWith(keyword string, causes []*OutputUnit, format string, args ...any) {
+	f.errors = append(f.errors, &OutputUnit{
+		KeywordLocation:         f.kwLoc + "/" + keyword,
+		AbsoluteKeywordLocation: f.node.keywordURI(keyword),
+		InstanceLocation:        f.instLoc,
+		Error:                   fmt.Sprintf(format, args...),
+		Errors:                  causes,
+	})
+}
+
+// annotate records the annotation produced by a keyword.
+func (f *evalFrame) annotate(keyword string, value any) {
+	if !f.st.annotate {
+		return
+	}
+	f.annotations = append(f.annotations, &OutputUnit{
+		Valid:                   true,
+		KeywordLocation:         f.kwLoc + "/" + keyword,
+		AbsoluteKeywordLocation: f.node.keywordURI(keyword),
+		InstanceLocation:        f.instLoc,
+		Annotation:              value,
+	})
+}
+
+// add records the result of evaluating a sub-schema.
+func (f *evalFrame) add(u *OutputUnit) {
+	switch {
+	case u == nil:
+	case !u.Valid:
+		f.errors = append(f.errors, u)
+	case f.st.verbose || len(u.Annotations) > 0 || u.Annotation != nil:
+		f.annotations = append(f.annotations, u)
+	}
+}
+
+// result builds the output unit of the evaluated schema. In the common case of
+// a passing schema without annotations to report it returns nil.
+func (f *evalFrame) result() *OutputUnit {
+	if len(f.errors) == 0 && !f.st.annotate {
+		return nil
+	}
+	u := &OutputUnit{
+		Valid:                   len(f.errors) == 0,
+		KeywordLocation:         f.kwLoc,
+		AbsoluteKeywordLocation: f.node.keywordURI(""),
+		InstanceLocation:        f.instLoc,
+	}
+	if u.Valid {
+		u.Annotations = f.annotations
+	} else {
+		u.Errors = f.errors
+	}
+	return u
+}
+
+func (st *evalState) eval(n *schemaNode, inst any, kwLoc, instLoc string) *OutputUnit {
+	u, _ := st.evalSchema(n, inst, kwLoc, instLoc)
+	return u
+}
+
+// evalIn evaluates a sub-schema applied in place to the instance of the frame,
+// merging the properties and items it evaluated into the frame when it passes.
+func (st *evalState) evalIn(f *evalFrame, n *schemaNode, inst any, kwLoc string) *OutputUnit {
+	u, seen := st.evalSchema(n, inst, kwLoc, f.instLoc)
+	if u.valid() {
+		f.seen.merge(seen)
+	}
+	return u
+}
+
+// evalSchema evaluates the schema and also returns the locations of the
+// instance it evaluated.
+func (st *evalState) evalSchema(n *schemaNode, inst any, kwLoc, instLoc string) (*OutputUnit, *evaluated) {
+	if n == nil {
+		return nil, nil
+	}
+	f := &evalFrame{st: st, node: n, kwLoc: kwLoc, instLoc: instLoc}
+	if n.boolean != nil {
+		if !*n.boolean {
+			return &OutputUnit{
+				KeywordLocation:         kwLoc,
+				AbsoluteKeywordLocation: n.keywordURI(""),
+				InstanceLocation:        instLoc,
+				Error:                   "false schema does not allow any value",
+			}, nil
+		}
+		return f.result(), nil
+	}
+	if st.track {
+		f.seen = new(evaluated)
+	}
+	if st.dynamic != nil {
+		if len(st.scope) == 0 || st.scope[len(st.scope)-1] != n.base {
+			st.scope = append(st.scope, n.base)
+			defer func() { st.scope = st.scope[:len(st.scope)-1] }()
+		}
+	}
+
+	if n.ref != nil {
+		f.add(st.evalRef(n.ref, "$ref", inst, f))
+	}
+	if n.dynamicRef != nil {
+		f.add(st.evalRef(st.resolveDynamic(n), "$dynamicRef", inst, f))
+	}
+
+	st.evalApplicators(n, inst, f)
+	st.evalAssertions(n, inst, f)
+
+	switch v := inst.(type) {
+	case map[string]any:
+		st.evalObject(n, v, f)
+	case []any:
+		st.evalArray(n, v, f)
+	case string:
+		st.evalString(n, v, f)
+	case nil, bool:
+	default:
+		st.evalNumber(n, v, f)
+	}
+	st.evalAnnotations(n, f)
+
+	return f.result(), f.seen
+}
+
+func (st *evalState) evalRef(target *schemaNode, keyword string, inst any, f *evalFrame) *OutputUnit {
+	kwLoc := f.kwLoc + "/" + keyword
+	for _, fr := range st.refs {
+		if fr.node == target && fr.instance == f.instLoc {
+			return &OutputUnit{
+				KeywordLocation:         kwLoc,
+				AbsoluteKeywordLocation: f.node.keywordURI(keyword),
+				InstanceLocation:        f.instLoc,
+				Error:                   "infinite recursion detected",
+			}
+		}
+	}
+	st.refs = append(st.refs, refFrame{node: target, instance: f.instLoc})
+	defer func() { st.refs = st.refs[:len(st.refs)-1] }()
+	return st.evalIn(f, target, inst, kwLoc)
+}
+
+// resolveDynamic finds the target of the "$dynamicRef" of n: the outermost
+// schema resource in the dynamic scope declaring a matching "$dynamicAnchor",
+// or the statically resolved schema otherwise.
+//
+// RFC draft-bhutton-json-schema-00 section 8.2.3.2
+func (st *evalState) resolveDynamic(n *schemaNode) *schemaNode {
+	if n.dynamicName == "" {
+		return n.dynamicRef
+	}
+	for _, base := range st.scope {
+		if target, ok := st.dynamic[base+"#"+n.dynamicName]; ok {
+			return target
+		}
+	}
+	return n.dynamicRef
+}
+
+// RFC draft-bhutton-json-schema-validation-00 section 9 and 7
+func (st *evalState) evalAnnotations(n *schemaNode, f *evalFrame) {
+	if !st.annotate {
+		return
+	}
+	s := n.schema
+	for _, a := range []struct {
+		keyword string
+		value   any
+		set     bool
+	}{
+		{"title", s.Title, s.Title != ""},
+		{"description", s.Description, s.Description != ""},
+		{"default", s.Default, s.Default != nil},
+		{"deprecated", s.Deprecated, s.Deprecated},
+		{"readOnly", s.ReadOnly, s.ReadOnly},
+		{"writeOnly", s.WriteOnly, s.WriteOnly},
+		{"examples", s.Examples, len(s.Examples) > 0},
+		{"format", s.Format, s.Format != ""},
+		{"contentEncoding", s.ContentEncoding, s.ContentEncoding != ""},
+		{"contentMediaType", s.ContentMediaType, s.ContentMediaType != ""},
+	} {
+		if a.set {
+			f.annotate(a.keyword, a.value)
+		}
+	}
+}
+
+// RFC draft-bhutton-json-schema-00 section 10.2
+func (st *evalState) evalApplicators(n *schemaNode, inst any, f *evalFrame) {
+	for i, sn := range n.allOf {
+		f.add(st.evalIn(f, sn, inst, f.kwLoc+"/allOf/"+strconv.Itoa(i)))
+	}
+	if len(n.anyOf) > 0 {
+		var errs, passed []*OutputUnit
+		for i, sn := range n.anyOf {
+			u := st.evalIn(f, sn, inst, f.kwLoc+"/anyOf/"+strconv.Itoa(i))
+			if !u.valid() {
+				errs = append(errs, u)
+				continue
+			}
+			passed = append(passed, u)
+			if !st.annotate && !st.track {
+				break
+			}
+		}
+		if len(passed) == 0 {
+			f.failWith("anyOf", errs, "value does not match any of the schemas in anyOf")
+		}
+		for _, u := range passed {
+			f.add(u)
+		}
+	}
+	if len(n.oneOf) > 0 {
+		var errs, passed []*OutputUnit
+		var matched []int
+		for i, sn := range n.oneOf {
+			u := st.evalIn(f, sn, inst, f.kwLoc+"/oneOf/"+strconv.Itoa(i))
+			if u.valid() {
+				matched = append(matched, i)
+				passed = append(passed, u)
+			} else {
+				errs = append(errs, u)
+			}
+		}
+		switch {
+		case len(matched) == 0:
+			f.failWith("oneOf", errs, "value does not match any of the schemas in oneOf")
+		case len(matched) > 1:
+			f.fail("oneOf", "value matches more than one schema in oneOf (indexes %v)", matched)
+		default:
+			f.add(passed[0])
+		}
+	}
+	if n.not != nil {
+		if st.evalQuiet(n.not, inst, f.kwLoc+"/not", f.instLoc).valid() {
+			f.fail("not", "value must not match the schema in not")
+		}
+	}
+	if n.ifNode != nil {
+		if u := st.evalIn(f, n.ifNode, inst, f.kwLoc+"/if"); u.valid() {
+			f.add(u)
+			f.add(st.evalIn(f, n.thenNode, inst, f.kwLoc+"/then"))
+		} else {
+			f.add(st.evalIn(f, n.elseNode, inst, f.kwLoc+"/else"))
+		}
+	}
+	if obj, ok := inst.(map[string]any); ok {
+		for _, dep := range n.dependentSchemas {
+			if _, present := obj[dep.name]; present {
+				f.add(st.evalIn(f, dep.node, inst, f.kwLoc+"/dependentSchemas/"+escapePointerToken(dep.name)))
+			}
+		}
+	}
+}
+
+// evalQuiet evaluates a sub-schema whose annotations are always discarded,
+// such as the one in "not".
+func (st *evalState) evalQuiet(n *schemaNode, inst any, kwLoc, instLoc string) *OutputUnit {
+	annotate, verbose := st.annotate, st.verbose
+	st.annotate, st.verbose = false, false
+	defer func() { st.annotate, st.verbose = annotate, verbose }()
+	return st.eval(n, inst, kwLoc, instLoc)
+}
+
+// RFC draft-bhutton-json-schema-validation-00 section 6.1
+func (st *evalState) evalAssertions(n *schemaNode, inst any, f *evalFrame) {
+	if len(n.types) > 0 && !typeIn(n.types, inst) {
+		if len(n.types) == 1 {
+			f.fail("type", "expected %s but got %s", n.types[0], instanceType(inst))
+		} else {
+			f.fail("type", "expected one of %v but got %s", n.types, instanceType(inst))
+		}
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(v any) bool { return jsonEqual(v, inst) }) {
+		f.fail("enum", "value must be one of the values in enum")
+	}
+	if n.hasConst && !jsonEqual(n.constant, inst) {
+		f.fail("const", "value must be equal to const")
+	}
+	for _, k := range n.keywords {
+		if err := k.eval(inst); err != nil {
+			f.fail(escapePointerToken(k.name), "%v", err)
+		}
+	}
+}
+
+// RFC draft-bhutton-json-schema-validation-00 section 6.2
+func (st *evalState) evalNumber(n *schemaNode, v any, f *evalFrame) {
+	if (n.multipleOf != nil || n.maximum != nil || n.exclusiveMaximum != nil || n.minimum != nil || n.exclusiveMinimum != nil) && !validNumber(v) {
+		f.fail("type", "%v is not a valid number", v)
+		return
+	}
+	if n.multipleOf != nil && !n.multipleOf.divides(v) {
+		f.fail("multipleOf", "%v is not a multiple of %s", v, n.multipleOf.text)
+	}
+	if n.maximum != nil && n.maximum.cmp(v) > 0 {
+		f.fail("maximum", "%v is greater than maximum %s", v, n.maximum.text)
+	}
+	if n.exclusiveMaximum != nil && n.exclusiveMaximum.cmp(v) >= 0 {
+		f.fail("exclusiveMaximum", "%v is not less than exclusiveMaximum %s", v, n.exclusiveMaximum.text)
+	}
+	if n.minimum != nil && n.minimum.cmp(v) < 0 {
+		f.fail("minimum", "%v is less than minimum %s", v, n.minimum.text)
+	}
+	if n.exclusiveMinimum != nil && n.exclusiveMinimum.cmp(v) <= 0 {
+		f.fail("exclusiveMinimum", "%v is not greater than exclusiveMinimum %s", v, n.exclusiveMinimum.text)
+	}
+}
+
+// RFC draft-bhutton-json-schema-validation-00 section 6.3
+func (st *evalState) evalString(n *schemaNode, str string, f *evalFrame) {
+	if n.maxLength >= 0 || n.minLength >= 0 {
+		l := int64(utf8.RuneCountInString(str))
+		if n.maxLength >= 0 && l > n.maxLength {
+			f.fail("maxLength", "length %d is greater than maxLength %d", l, n.maxLength)
+		}
+		if n.minLength >= 0 && l < n.minLength {
+			f.fail("minLength", "length %d is less than minLength %d", l, n.minLength)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(str) {
+		f.fail("pattern", "value does not match pattern %q", n.pattern.String())
+	}
+	if n.format != nil && !n.format(str) {
+		f.fail("format", "value is not a valid %q", n.schema.Format)
+	}
+	if n.decodeContent != nil || n.parseContent != nil {
+		st.evalContent(n, str, f)
+	}
+}
+
+// RFC draft-bhutton-json-schema-validation-00 section 8
+func (st *evalState) evalContent(n *schemaNode, str string, f *evalFrame) {
+	data := []byte(str)
+	if n.decodeContent != nil {
+		b, err := n.decodeContent(str)
+		if err != nil {
+			f.fail("contentEncoding", "value is not valid %s: %v", n.schema.ContentEncoding, err)
+			return
+		}
+		data = b
+	}
+	if n.parseContent == nil {
+		return
+	}
+	doc, err := n.parseContent(data)
+	if err != nil {
+		f.fail("contentMediaType", "value is not a valid %s document: %v", n.schema.ContentMediaType, err)
+		return
+	}
+	if n.contentSchema == nil {
+		return
+	}
+	// The decoded document is a separate instance, so its annotations are
+	// not reported as if they applied to the string.
+	if u := st.evalQuiet(n.contentSchema, doc, f.kwLoc+"/contentSchema", f.instLoc); !u.valid() {
+		f.failWith("contentSchema", []*OutputUnit{u}, "decoded content does not match contentSchema")
+	}
+}
+
+// RFC draft-bhutton-json-schema-00 section 10.3.1 and
+// RFC draft-bhutton-json-schema-validation-00 section 6.4
+func (st *evalState) evalArray(n *schemaNode, arr []any, f *evalFrame) {
+	l := int64(len(arr))
+	if n.maxItems >= 0 && l > n.maxItems {
+		f.fail("maxItems", "array has %d items, more than maxItems %d", l, n.maxItems)
+	}
+	if n.minItems >= 0 && l < n.minItems {
+		f.fail("minItems", "array has %d items, fewer than minItems %d", l, n.minItems)
+	}
+	if n.uniqueItems {
+		if i, j, dup := findDuplicate(arr); dup {
+			f.fail("uniqueItems", "items at indexes %d and %d are equal", i, j)
+		}
+	}
+
+	for i, sn := range n.prefixItems {
+		if i >= len(arr) {
+			break
+		}
+		f.add(st.eval(sn, arr[i], f.kwLoc+"/prefixItems/"+strconv.Itoa(i), f.instLoc+"/"+strconv.Itoa(i)))
+	}
+	if len(n.prefixItems) > 0 && len(arr) > 0 {
+		f.seen.addItems(min(len(arr), len(n.prefixItems)), false)
+		if len(arr) > len(n.prefixItems) {
+			f.annotate("prefixItems", len(n.prefixItems)-1)
+		} else {
+			f.annotate("prefixItems", true)
+		}
+	}
+	if n.items != nil && len(arr) > len(n.prefixItems) {
+		for i := len(n.prefixItems); i < len(arr); i++ {
+			f.add(st.eval(n.items, arr[i], f.kwLoc+"/items", f.instLoc+"/"+strconv.Itoa(i)))
+		}
+		f.seen.addItems(0, true)
+		f.annotate("items", true)
+	}
+	if n.contains != nil {
+		var matched []int
+		for i, item := range arr {
+			if st.evalQuiet(n.contains, item, f.kwLoc+"/contains", f.instLoc+"/"+strconv.Itoa(i)).valid() {
+				matched = append(matched, i)
+			}
+		}
+		matches := int64(len(matched))
+		switch {
+		case n.minContains >= 0 && matches < n.minContains:
+			f.fail("minContains", "array contains %d matching items, fewer than minContains %d", matches, n.minContains)
+		case n.minContains < 0 && matches == 0:
+			f.fail("contains", "array does not contain a matching item")
+		}
+		if n.maxContains >= 0 && matches > n.maxContains {
+			f.fail("maxContains", "array contains %d matching items, more than maxContains %d", matches, n.maxContains)
+		}
+		if matches > 0 {
+			f.seen.addIndexes(matched)
+			f.annotate("contains", matched)
+		}
+	}
+
+	// RFC draft-bhutton-json-schema-00 section 11.2
+	if n.unevaluatedItems != nil {
+		var unevaluated bool
+		for i, item := range arr {
+			if !f.seen.hasItem(i) {
+				unevaluated = true
+				f.add(st.eval(n.unevaluatedItems, item, f.kwLoc+"/unevaluatedItems", f.instLoc+"/"+strconv.Itoa(i)))
+			}
+		}
+		f.seen.addItems(0, true)
+		if unevaluated {
+			f.annotate("unevaluatedItems", true)
+		}
+	}
+}
+
+// RFC draft-bhutton-json-schema-00 section 10.3.2 and
+// RFC draft-bhutton-json-schema-validation-00 section 6.5
+func (st *evalState) evalObject(n *schemaNode, obj map[string]any, f *evalFrame) {
+	l := int64(len(obj))
+	if n.maxProperties >= 0 && l > n.maxProperties {
+		f.fail("maxProperties", "object has %d properties, more than maxProperties %d", l, n.maxProperties)
+	}
+	if n.minProperties >= 0 && l < n.minProperties {
+		f.fail("minProperties", "object has %d properties, fewer than minProperties %d", l, n.minProperties)
+	}
+	var missing []string
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		f.fail("required", "missing required properties %q", missing)
+	}
+	for _, dep := range n.dependentRequired {
+		if _, ok := obj[dep.name]; !ok {
+			continue
+		}
+		for _, name := range dep.values {
+			if _, ok := obj[name]; !ok {
+				f.fail("dependentRequired/"+escapePointerToken(dep.name), "property %q is required when %q is present", name, dep.name)
+			}
+		}
+	}
+
+	if n.properties == nil && n.patternProperties == nil && n.additionalProperties == nil && n.propertyNames == nil && n.unevaluatedProperties == nil {
+		return
+	}
+	// the properties are evaluated in map order and the results sorted by
+	// property afterwards, when there are several to report
+	errStart, annStart := len(f.errors), len(f.annotations)
+	var props, patternProps, additionalProps []string
+	for name, value := range obj {
+		propLoc := f.instLoc + "/" + escapePointerToken(name)
+		matched := false
+		if sn, ok := n.properties[name]; ok {
+			matched = true
+			props = append(props, name)
+			f.add(st.eval(sn, value, f.kwLoc+"/properties/"+escapePointerToken(name), propLoc))
+		}
+		patternMatched := false
+		for _, pp := range n.patternProperties {
+			if pp.re.MatchString(name) {
+				patternMatched = true
+				f.add(st.eval(pp.node, value, f.kwLoc+"/patternProperties/"+escapePointerToken(pp.pattern), propLoc))
+			}
+		}
+		if patternMatched {
+			matched = true
+			patternProps = append(patternProps, name)
+		}
+		if !matched && n.additionalProperties != nil {
+			additionalProps = append(additionalProps, name)
+			f.add(st.eval(n.additionalProperties, value, f.kwLoc+"/additionalProperties", propLoc))
+		}
+		if n.propertyNames != nil {
+			f.add(st.eval(n.propertyNames, name, f.kwLoc+"/propertyNames", propLoc))
+		}
+	}
+	f.sortByProperty(errStart, annStart)
+	f.annotateProps("properties", props)
+	f.annotateProps("patternProperties", patternProps)
+	f.annotateProps("additionalProperties", additionalProps)
+	f.seen.addProps(props)
+	f.seen.addProps(patternProps)
+	f.seen.addProps(additionalProps)
+
+	// RFC draft-bhutton-json-schema-00 section 11.3
+	if n.unevaluatedProperties != nil {
+		errStart, annStart := len(f.errors), len(f.annotations)
+		var unevaluated []string
+		for name, value := range obj {
+			if !f.seen.props[name] {
+				unevaluated = append(unevaluated, name)
+				f.add(st.eval(n.unevaluatedProperties, value, f.kwLoc+"/unevaluatedProperties", f.instLoc+"/"+escapePointerToken(name)))
+			}
+		}
+		f.sortByProperty(errStart, annStart)
+		f.seen.addProps(unevaluated)
+		f.annotateProps("unevaluatedProperties", unevaluated)
+	}
+}
+
+// sortByProperty sorts the errors and annotations added to the frame since
+// the given lengths by the name of the property of the object they were
+// evaluated for, keeping the order of the results of each property.
+func (f *evalFrame) sortByProperty(errStart, annStart int) {
+	byProperty := func(a, b *OutputUnit) int {
+		return strings.Compare(f.propertyName(a), f.propertyName(b))
+	}
+	if len(f.errors)-errStart > 1 {
+		slices.SortStableFunc(f.errors[errStart:], byProperty)
+	}
+	if len(f.annotations)-annStart > 1 {
+		slices.SortStableFunc(f.annotations[annStart:], byProperty)
+	}
+}
+
+// propertyName returns the name of the property of the object of the frame
+// that the result of a sub-schema refers to.
+func (f *evalFrame) propertyName(u *OutputUnit) string {
+	return unescapePointerToken(strings.TrimPrefix(u.InstanceLocation, f.instLoc+"/"))
+}
+
+// annotateProps records the sorted names of the properties evaluated by a
+// keyword, if any.
+func (f *evalFrame) annotateProps(keyword string, names []string) {
+	if len(names) == 0 || !f.st.annotate {
+		return
+	}
+	slices.Sort(names)
+	f.annotate(keyword, names)
+}
+
+// valid reports whether a sub-schema evaluation passed; a nil unit is the
+// result of a passing schema with nothing to report.
+func (u *OutputUnit) valid() bool {
+	return u == nil || u.Valid
+}
+
+// normalizeInstance makes sure the instance only contains the types produced
+// by decoding JSON into an `any`, encoding other Go values as needed.
+func normalizeInstance(v any) (any, error) {
+	if isJSONValue(v) {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: cannot encode instance: %w", err)
+	}
+	return decodeInstance(data)
+}
+
+// decodeInstance decodes a JSON document preserving the exact text of numbers.
+func decodeInstance(data []byte) (any, error) {
+	dec := jsonv1.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("jsonschema: cannot decode instance: %w", err)
+	}
+	return v, nil
+}
+
+func isJSONValue(v any) bool {
+	switch v := v.(type) {
+	case nil, bool, string, jsonv1.Number:
+		return true
+	case float64:
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return true
+	case []any:
+		return !slices.ContainsFunc(v, func(item any) bool { return !isJSONValue(item) })
+	case map[string]any:
+		for _, item := range v {
+			if !isJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// instanceType returns the JSON data model type of a normalized instance.
+func instanceType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if r, ok := toRat(v); ok {
+		if r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
+
+func typeMatches(typ string, v any) bool {
+	it := instanceType(v)
+	return it == typ || (typ == "number" && it == "integer")
+}
+
+// toRat converts a numeric instance into an exact rational number.
+func toRat(v any) (*big.Rat, bool) {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		// Use the shortest decimal representation, which is the JSON text the
+		// float was most likely decoded from, rather than its binary value.
+		return new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	case jsonv1.Number:
+		return new(big.Rat).SetString(string(v))
+	case float32:
+		return toRat(float64(v))
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	}
+	return nil, false
+}
+
+// jsonEqual compares two JSON values, treating numbers with the same value
+// as equal regardless of their representation.
+func jsonEqual(a, b any) bool {
+	if !isJSONValue(a) {
+		if na, err := normalizeInstance(a); err == nil {
+			a = na
+		}
+	}
+	if !isJSONValue(b) {
+		if nb, err := normalizeInstance(b); err == nil {
+			b = nb
+		}
+	}
+	if ra, ok := toRat(a); ok {
+		rb, ok := toRat(b)
+		return ok && ra.Cmp(rb) == 0
+	}
+	if _, ok := toRat(b); ok {
+		return false
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, va := range a {
+			vb, ok := b[k]
+			if !ok || !jsonEqual(va, vb) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func findDuplicate(arr []any) (int, int, bool) {
+	for i := range arr {
+		for j := i + 1; j < len(arr); j++ {
+			if jsonEqual(arr[i], arr[j]) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// escapePointerToken escapes a single JSON Pointer reference token (RFC 6901).
+func escapePointerToken(s string) string {
+	if !strings.ContainsAny(s, "~/") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// unescapePointerToken reverses escapePointerToken.
+func unescapePointerToken(s string) string {
+	if !strings.Contains(s, "~") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
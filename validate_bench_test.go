@@ -0,0 +1,112 @@
+package jsonschema
+
+import (
+	"testing"
+	"time"
+)
+
+// Benchmarks compare validating with a compiled Validator against
+// Schema.Validate, which walks and prepares the schema tree on every call.
+
+type benchAddress struct {
+	Street  string `json:"street" jsonschema:"minLength=1,maxLength=128"`
+	City    string `json:"city" jsonschema:"minLength=1"`
+	Country string `json:"country" jsonschema:"pattern=^[A-Z]{2}$"`
+}
+
+type benchLine struct {
+	SKU      string  `json:"sku" jsonschema:"pattern=^[A-Z0-9-]{4\\,16}$"`
+	Quantity int     `json:"quantity" jsonschema:"minimum=1,maximum=1000"`
+	Price    float64 `json:"price" jsonschema:"exclusiveMinimum=0,multipleOf=0.01"`
+}
+
+type benchOrder struct {
+	ID       string         `json:"id" jsonschema:"format=uuid,minLength=36,maxLength=36"`
+	Status   string         `json:"status" jsonschema:"enum=pending,enum=paid,enum=shipped"`
+	Created  time.Time      `json:"created"`
+	Shipping benchAddress   `json:"shipping"`
+	Lines    []benchLine    `json:"lines" jsonschema:"minItems=1,maxItems=100"`
+	Tags     []string       `json:"tags,omitempty" jsonschema:"uniqueItems=true"`
+	Meta     map[string]int `json:"meta,omitempty"`
+}
+
+func newBenchOrder() *benchOrder {
+	return &benchOrder{
+		ID:       "3f1c1c9e-6a5e-4d8f-9d3e-2b2f7d0c1a11",
+		Status:   "paid",
+		Created:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Shipping: benchAddress{Street: "1 Infinite Loop", City: "Cupertino", Country: "US"},
+		Lines: []benchLine{
+			{SKU: "ABC-123", Quantity: 2, Price: 9.99},
+			{SKU: "XYZ-999", Quantity: 1, Price: 120.5},
+			{SKU: "QQQ-001", Quantity: 10, Price: 0.25},
+		},
+		Tags: []string{"gift", "express"},
+		Meta: map[string]int{"priority": 1},
+	}
+}
+
+func benchInstance(b *testing.B) any {
+	b.Helper()
+	inst, err := normalizeInstance(newBenchOrder())
+	if err != nil {
+		b.Fatal(err)
+	}
+	return inst
+}
+
+func BenchmarkSchemaValidate(b *testing.B) {
+	schema := Reflect(&benchOrder{})
+	inst := benchInstance(b)
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if err := schema.Validate(inst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidatorValidate(b *testing.B) {
+	v, err := Compile(Reflect(&benchOrder{}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	inst := benchInstance(b)
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if err := v.Validate(inst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidatorValidateParallel(b *testing.B) {
+	v, err := Compile(Reflect(&benchOrder{}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	inst := benchInstance(b)
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := v.Validate(inst); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkCompile(b *testing.B) {
+	schema := Reflect(&benchOrder{})
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := Compile(schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
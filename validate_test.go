@@ -0,0 +1,264 @@
+package jsonschema
+
+import (
+	json "encoding/json/v2"
+	"errors"
+	"testing"
+
+	jsonv1 "github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustUnmarshalSchema(t testing.TB, data string) *Schema {
+	t.Helper()
+	s := new(Schema)
+	require.NoError(t, json.Unmarshal([]byte(data), s))
+	return s
+}
+
+func mustDecodeInstance(t testing.TB, data string) any {
+	t.Helper()
+	v, err := decodeInstance([]byte(data))
+	require.NoError(t, err)
+	return v
+}
+
+func TestValidateKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{"true schema", `true`, `{"a":1}`, true},
+		{"false schema", `false`, `1`, false},
+		{"type string", `{"type":"string"}`, `"x"`, true},
+		{"type string mismatch", `{"type":"string"}`, `1`, false},
+		{"type integer accepts 1.0", `{"type":"integer"}`, `1.0`, true},
+		{"type integer rejects 1.5", `{"type":"integer"}`, `1.5`, false},
+		{"type number accepts integer", `{"type":"number"}`, `3`, true},
+		{"type array of types", `{"type":["string","null"]}`, `null`, true},
+		{"enum", `{"enum":[1,"a",{"b":[true]}]}`, `{"b":[true]}`, true},
+		{"enum mismatch", `{"enum":[1,"a"]}`, `"b"`, false},
+		{"const number", `{"const":2}`, `2.0`, true},
+		{"const mismatch", `{"const":"a"}`, `"b"`, false},
+		{"multipleOf decimal", `{"multipleOf":0.01}`, `19.99`, true},
+		{"multipleOf mismatch", `{"multipleOf":2}`, `7`, false},
+		{"maximum", `{"maximum":3}`, `3`, true},
+		{"exclusiveMaximum", `{"exclusiveMaximum":3}`, `3`, false},
+		{"minimum", `{"minimum":1.5}`, `1`, false},
+		{"exclusiveMinimum", `{"exclusiveMinimum":1}`, `1.0001`, true},
+		{"minLength counts code points", `{"minLength":2}`, `"💩"`, false},
+		{"maxLength", `{"maxLength":2}`, `"abc"`, false},
+		{"pattern", `{"pattern":"^a+$"}`, `"aaa"`, true},
+		{"pattern ignores non strings", `{"pattern":"^a+$"}`, `12`, true},
+		{"minItems", `{"minItems":2}`, `[1]`, false},
+		{"maxItems", `{"maxItems":2}`, `[1,2]`, true},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,1.0]`, false},
+		{"uniqueItems objects", `{"uniqueItems":true}`, `[{"a":1},{"a":2}]`, true},
+		{"prefixItems", `{"prefixItems":[{"type":"integer"},{"type":"string"}]}`, `[1,"a",null]`, true},
+		{"prefixItems mismatch", `{"prefixItems":[{"type":"integer"},{"type":"string"}]}`, `["a"]`, false},
+		{"items after prefixItems", `{"prefixItems":[{"type":"integer"}],"items":{"type":"string"}}`, `[1,"a",2]`, false},
+		{"contains", `{"contains":{"type":"string"}}`, `[1,"a"]`, true},
+		{"contains mismatch", `{"contains":{"type":"string"}}`, `[1,2]`, false},
+		{"minContains", `{"contains":{"const":1},"minContains":2}`, `[1,2,1]`, true},
+		{"minContains zero", `{"contains":{"const":1},"minContains":0}`, `[]`, true},
+		{"maxContains", `{"contains":{"const":1},"maxContains":1}`, `[1,1]`, false},
+		{"required", `{"required":["a","b"]}`, `{"a":1}`, false},
+		{"minProperties", `{"minProperties":1}`, `{}`, false},
+		{"maxProperties", `{"maxProperties":1}`, `{"a":1,"b":2}`, false},
+		{"properties", `{"properties":{"a":{"type":"string"}}}`, `{"a":1}`, false},
+		{"patternProperties", `{"patternProperties":{"^x-":{"type":"string"}}}`, `{"x-a":"b","y":1}`, true},
+		{"patternProperties mismatch", `{"patternProperties":{"^x-":{"type":"string"}}}`, `{"x-a":1}`, false},
+		{"additionalProperties", `{"properties":{"a":true},"patternProperties":{"^b":true},"additionalProperties":false}`, `{"a":1,"bb":2}`, true},
+		{"additionalProperties mismatch", `{"properties":{"a":true},"additionalProperties":false}`, `{"a":1,"c":2}`, false},
+		{"propertyNames", `{"propertyNames":{"maxLength":3}}`, `{"abcd":1}`, false},
+		{"dependentRequired", `{"dependentRequired":{"a":["b"]}}`, `{"a":1}`, false},
+		{"dependentRequired absent", `{"dependentRequired":{"a":["b"]}}`, `{"c":1}`, true},
+		{"dependentSchemas", `{"dependentSchemas":{"a":{"required":["b"]}}}`, `{"a":1}`, false},
+		{"allOf", `{"allOf":[{"minimum":1},{"maximum":3}]}`, `4`, false},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"minimum":3}]}`, `4`, true},
+		{"anyOf mismatch", `{"anyOf":[{"type":"string"},{"minimum":3}]}`, `2`, false},
+		{"oneOf", `{"oneOf":[{"type":"integer"},{"minimum":3}]}`, `2`, true},
+		{"oneOf more than one", `{"oneOf":[{"type":"integer"},{"minimum":3}]}`, `4`, false},
+		{"not", `{"not":{"type":"string"}}`, `"a"`, false},
+		{"if then", `{"if":{"minimum":10},"then":{"multipleOf":2},"else":{"multipleOf":3}}`, `12`, true},
+		{"if then mismatch", `{"if":{"minimum":10},"then":{"multipleOf":2},"else":{"multipleOf":3}}`, `11`, false},
+		{"if else", `{"if":{"minimum":10},"then":{"multipleOf":2},"else":{"multipleOf":3}}`, `9`, true},
+		{"ref to defs", `{"$ref":"#/$defs/pos","$defs":{"pos":{"minimum":0}}}`, `-1`, false},
+		{"ref with siblings", `{"$ref":"#/$defs/pos","maximum":5,"$defs":{"pos":{"minimum":0}}}`, `6`, false},
+		{"ref to root", `{"properties":{"child":{"$ref":"#"}},"required":["v"]}`, `{"v":1,"child":{"v":2,"child":{}}}`, false},
+		{"ref to anchor", `{"$ref":"#pos","$defs":{"pos":{"$anchor":"pos","minimum":0}}}`, `1`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustUnmarshalSchema(t, tt.schema)
+			err := s.Validate(mustDecodeInstance(t, tt.instance))
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateErrorLocations(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"$ref": "#/$defs/User",
+		"$defs": {
+			"User": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "minLength": 1},
+					"friends/ids": {"type": "array", "items": {"type": "integer"}}
+				}
+			}
+		}
+	}`)
+
+	err := s.Validate(mustDecodeInstance(t, `{"name":"","friends/ids":[1,"two"]}`))
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	leaves := verr.leaves(nil)
+	require.Len(t, leaves, 2)
+	assert.Equal(t, "/$ref/properties/friends~1ids/items/type", leaves[0].KeywordLocation)
+	assert.Equal(t, "/friends~1ids/1", leaves[0].InstanceLocation)
+	assert.Equal(t, "/$ref/properties/name/minLength", leaves[1].KeywordLocation)
+	assert.Equal(t, "/name", leaves[1].InstanceLocation)
+	assert.Contains(t, err.Error(), "/name: length 0 is less than minLength 1")
+}
+
+func TestValidateErrorOrder(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{
+		"properties": {"a": {"type": "string"}, "b~c": {"type": "string"}},
+		"patternProperties": {"^[a-z]": {"maxLength": 1}},
+		"propertyNames": {"not": {"const": "e"}},
+		"unevaluatedProperties": false
+	}`)
+	v, err := Compile(s)
+	require.NoError(t, err)
+	inst := mustDecodeInstance(t, `{"e": "xx", "a": 1, "b~c": "xx", "Z": 0, "0/e": 0, "f": "xx"}`)
+
+	for range 10 {
+		var verr *ValidationError
+		require.True(t, errors.As(v.Validate(inst), &verr))
+		var got []string
+		for _, leaf := range verr.leaves(nil) {
+			got = append(got, leaf.KeywordLocation+" "+leaf.InstanceLocation)
+		}
+		assert.Equal(t, []string{
+			"/properties/a/type /a",
+			"/patternProperties/^[a-z]/maxLength /b~0c",
+			"/patternProperties/^[a-z]/maxLength /e",
+			"/propertyNames/not /e",
+			"/patternProperties/^[a-z]/maxLength /f",
+			"/unevaluatedProperties /0~1e",
+			"/unevaluatedProperties /Z",
+		}, got)
+	}
+}
+
+func TestValidateReflectedSchema(t *testing.T) {
+	type Address struct {
+		Street string `json:"street" jsonschema:"minLength=1"`
+	}
+	type Person struct {
+		Name    string   `json:"name" jsonschema:"pattern=^[A-Z]"`
+		Age     int      `json:"age" jsonschema:"minimum=0,maximum=150"`
+		Tags    []string `json:"tags,omitempty" jsonschema:"uniqueItems=true"`
+		Address *Address `json:"address,omitempty"`
+	}
+
+	s := Reflect(&Person{})
+	assert.NoError(t, s.Validate(&Person{Name: "Ann", Age: 30, Address: &Address{Street: "Main"}}))
+	assert.Error(t, s.Validate(&Person{Name: "ann", Age: 30}))
+	assert.Error(t, s.Validate(&Person{Name: "Ann", Age: 200}))
+	assert.Error(t, s.Validate(&Person{Name: "Ann", Tags: []string{"a", "a"}}))
+	assert.Error(t, s.Validate(&Person{Name: "Ann", Address: &Address{}}))
+	assert.Error(t, s.Validate(map[string]any{"name": "Ann", "age": 1, "extra": true}))
+}
+
+func TestValidateMalformedNumber(t *testing.T) {
+	for _, schema := range []string{`{"minimum": 1}`, `{"exclusiveMaximum": 1}`, `{"multipleOf": 0.5}`} {
+		var verr *ValidationError
+		require.ErrorAs(t, mustUnmarshalSchema(t, schema).Validate(jsonv1.Number("1x")), &verr, schema)
+	}
+	assert.NoError(t, mustUnmarshalSchema(t, `{"minimum": 1}`).Validate(jsonv1.Number("2")))
+}
+
+func TestValidateUnresolvableRef(t *testing.T) {
+	s := mustUnmarshalSchema(t, `{"$ref":"#/$defs/missing"}`)
+	err := s.Validate(1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot resolve $ref")
+}
+
+func TestValidateUnevaluated(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{"properties", `{"properties":{"a":true},"unevaluatedProperties":false}`, `{"a":1}`, true},
+		{"properties extra", `{"properties":{"a":true},"unevaluatedProperties":false}`, `{"a":1,"b":2}`, false},
+		{"schema for extra", `{"unevaluatedProperties":{"type":"integer"}}`, `{"a":1,"b":"x"}`, false},
+		{"allOf", `{"allOf":[{"properties":{"a":true}}],"properties":{"b":true},"unevaluatedProperties":false}`, `{"a":1,"b":2}`, true},
+		{"allOf extra", `{"allOf":[{"properties":{"a":true}}],"unevaluatedProperties":false}`, `{"a":1,"c":2}`, false},
+		{"additionalProperties does not see allOf", `{"allOf":[{"properties":{"a":true}}],"additionalProperties":false}`, `{"a":1}`, false},
+		{"anyOf all passing branches", `{"anyOf":[{"properties":{"a":true}},{"properties":{"b":true}}],"unevaluatedProperties":false}`, `{"a":1,"b":2}`, true},
+		{"anyOf failing branch ignored", `{"anyOf":[{"properties":{"a":true}},{"properties":{"b":{"type":"string"}},"required":["b"]}],"unevaluatedProperties":false}`, `{"a":1,"b":2}`, false},
+		{"oneOf", `{"oneOf":[{"properties":{"a":{"const":1}},"required":["a"]},{"properties":{"b":true},"required":["b"]}],"unevaluatedProperties":false}`, `{"b":1}`, true},
+		{"if then", `{"if":{"properties":{"kind":{"const":"x"}}},"then":{"properties":{"x":true}},"else":{"properties":{"y":true}},"unevaluatedProperties":false}`, `{"kind":"x","x":1}`, true},
+		{"if then else branch not applied", `{"if":{"properties":{"kind":{"const":"x"}}},"then":{"properties":{"x":true}},"else":{"properties":{"y":true}},"unevaluatedProperties":false}`, `{"kind":"x","y":1}`, false},
+		{"not is ignored", `{"not":{"not":{"properties":{"a":true}}},"unevaluatedProperties":false}`, `{"a":1}`, false},
+		{"ref", `{"$ref":"#/$defs/base","properties":{"b":true},"unevaluatedProperties":false,"$defs":{"base":{"properties":{"a":true}}}}`, `{"a":1,"b":2}`, true},
+		{"nested unevaluatedProperties", `{"allOf":[{"unevaluatedProperties":true}],"unevaluatedProperties":false}`, `{"a":1}`, true},
+		{"dependentSchemas", `{"dependentSchemas":{"a":{"properties":{"b":true}}},"properties":{"a":true},"unevaluatedProperties":false}`, `{"a":1,"b":2}`, true},
+		{"nested objects are separate", `{"properties":{"o":{"properties":{"a":true}}},"unevaluatedProperties":false}`, `{"o":{"a":1,"b":2}}`, true},
+		{"items", `{"prefixItems":[{"type":"integer"}],"unevaluatedItems":false}`, `[1]`, true},
+		{"items extra", `{"prefixItems":[{"type":"integer"}],"unevaluatedItems":false}`, `[1,2]`, false},
+		{"items allOf", `{"allOf":[{"prefixItems":[true,true]}],"prefixItems":[true],"unevaluatedItems":false}`, `[1,2]`, true},
+		{"items keyword", `{"allOf":[{"items":true}],"unevaluatedItems":false}`, `[1,2]`, true},
+		{"items contains", `{"contains":{"type":"string"},"unevaluatedItems":{"type":"integer"}}`, `["a",1,"b"]`, true},
+		{"items contains mismatch", `{"contains":{"type":"string"},"unevaluatedItems":{"type":"integer"}}`, `["a",true]`, false},
+		{"items ref", `{"$ref":"#/$defs/pair","unevaluatedItems":false,"$defs":{"pair":{"prefixItems":[true,true]}}}`, `[1,2,3]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Compile(mustUnmarshalSchema(t, tt.schema))
+			require.NoError(t, err)
+			inst := mustDecodeInstance(t, tt.instance)
+			if tt.valid {
+				assert.NoError(t, v.Validate(inst))
+			} else {
+				assert.Error(t, v.Validate(inst))
+			}
+			out, err := v.Evaluate(inst, DetailedOutput)
+			require.NoError(t, err)
+			assert.Equal(t, tt.valid, out.Valid, "Evaluate must agree with Validate")
+		})
+	}
+}
+
+func TestValidateComposedUnevaluatedProperties(t *testing.T) {
+	type Base struct {
+		ID string `json:"id"`
+	}
+	type Derived struct {
+		Name string `json:"name"`
+	}
+
+	r := &Reflector{AllowAdditionalProperties: true, DoNotReference: true}
+	s := &Schema{AllOf: []*Schema{r.Reflect(&Base{}), r.Reflect(&Derived{})}, UnevaluatedProperties: FalseSchema}
+
+	v, err := Compile(s)
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(map[string]any{"id": "1", "name": "x"}))
+	assert.Error(t, v.Validate(map[string]any{"id": "1", "name": "x", "extra": true}))
+}
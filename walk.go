@@ -0,0 +1,117 @@
+package jsonschema
+
+import (
+	"errors"
+	"iter"
+)
+
+// Pointer is a JSON Pointer designating a sub-schema relative to the schema
+// being walked, such as "/properties/name/items". The empty Pointer
+// designates the schema itself.
+//
+// RFC 6901
+type Pointer string
+
+// SkipSubtree is used as a return value from the functions given to Walk and
+// Rewrite to indicate that the sub-schemas of the schema are to be skipped.
+// It is not returned as an error by any function.
+var SkipSubtree = errors.New("skip this subtree")
+
+// Walk calls fn for the schema and then for each of its sub-schemas,
+// recursively, with their location. Every keyword holding sub-schemas is
+// visited: "$defs" first, then "allOf", "anyOf", "oneOf" and "prefixItems",
+// the keywords taking a single schema such as "not" or "items", then
+// "dependentSchemas", "patternProperties" and "properties". Map entries are
+// visited in sorted order, properties in their order.
+//
+// When fn returns SkipSubtree, the sub-schemas of the schema are not visited.
+// Any other error stops the walk and is returned. References are not
+// followed. Keywords can be changed during the walk, but sub-schemas must not
+// be added or removed; see Rewrite to replace them. A nil root is not
+// visited.
+func Walk(root *Schema, fn func(path Pointer, s *Schema) error) error {
+	if err := walk(root, "", fn); err != nil && err != SkipSubtree {
+		return err
+	}
+	return nil
+}
+
+func walk(s *Schema, ptr Pointer, fn func(Pointer, *Schema) error) error {
+	if s == nil {
+		return nil
+	}
+	if err := fn(ptr, s); err != nil {
+		return err
+	}
+	for p, sub := range s.subschemas() {
+		if err := walk(sub, ptr+Pointer(p), fn); err != nil && err != SkipSubtree {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schemas iterates over the schema and all its sub-schemas with their
+// location, in the order of Walk.
+func Schemas(root *Schema) iter.Seq2[Pointer, *Schema] {
+	return func(yield func(Pointer, *Schema) bool) {
+		stop := errors.New("stop")
+		_ = Walk(root, func(path Pointer, s *Schema) error {
+			if !yield(path, s) {
+				return stop
+			}
+			return nil
+		})
+	}
+}
+
+// Rewrite calls fn for the schema and its sub-schemas, in the order of Walk,
+// and replaces each of them with the schema returned by fn. The sub-schemas
+// visited next are the ones of the returned schema. A nil schema removes the
+// keyword or entry holding a sub-schema, which is not possible for an
+// element of a list such as "allOf". The schemas are modified in place and
+// the rewritten root is returned.
+//
+// When fn returns SkipSubtree, the returned schema is used but its
+// sub-schemas are not visited. Any other error stops the rewrite and is
+// returned. A nil root is not visited and nil is returned.
+func Rewrite(root *Schema, fn func(path Pointer, s *Schema) (*Schema, error)) (*Schema, error) {
+	s, err := rewrite(root, "", fn)
+	if err != nil && err != SkipSubtree {
+		return nil, err
+	}
+	return s, nil
+}
+
+func rewrite(s *Schema, ptr Pointer, fn func(Pointer, *Schema) (*Schema, error)) (*Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s, err := fn(ptr, s)
+	if err != nil || s == nil {
+		return s, err
+	}
+	type child struct {
+		path   string
+		schema *Schema
+	}
+	var children []child
+	for p, sub := range s.subschemas() {
+		if sub != nil {
+			children = append(children, child{p, sub})
+		}
+	}
+	for _, c := range children {
+		sub, err := rewrite(c.schema, ptr+Pointer(c.path), fn)
+		if err != nil && err != SkipSubtree {
+			return nil, err
+		}
+		if sub == c.schema {
+			continue
+		}
+		if err := s.Set(c.path, sub); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,139 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const walkSchema = `{
+	"$defs": {"b": {"type": "string"}, "a": {"items": {"$ref": "#/$defs/b"}}},
+	"allOf": [{"minProperties": 1}],
+	"properties": {
+		"z": {"not": true, "contentSchema": {"type": "integer"}},
+		"y": {"dependentSchemas": {"k": false}, "prefixItems": [{}, {"type": "null"}]}
+	},
+	"additionalProperties": false
+}`
+
+func TestWalk(t *testing.T) {
+	s := mustUnmarshalSchema(t, walkSchema)
+
+	var paths []Pointer
+	require.NoError(t, Walk(s, func(path Pointer, _ *Schema) error {
+		paths = append(paths, path)
+		return nil
+	}))
+	assert.Equal(t, []Pointer{
+		"",
+		"/$defs/a",
+		"/$defs/a/items",
+		"/$defs/b",
+		"/allOf/0",
+		"/additionalProperties",
+		"/properties/z",
+		"/properties/z/not",
+		"/properties/z/contentSchema",
+		"/properties/y",
+		"/properties/y/prefixItems/0",
+		"/properties/y/prefixItems/1",
+		"/properties/y/dependentSchemas/k",
+	}, paths)
+
+	for path, sub := range Schemas(s) {
+		at, err := s.At(string(path))
+		require.NoError(t, err)
+		assert.Same(t, at, sub, path)
+	}
+
+	paths = nil
+	require.NoError(t, Walk(s, func(path Pointer, _ *Schema) error {
+		paths = append(paths, path)
+		if path == "/$defs/a" || path == "/properties" || path == "/properties/y" {
+			return SkipSubtree
+		}
+		return nil
+	}))
+	assert.NotContains(t, paths, Pointer("/$defs/a/items"))
+	assert.NotContains(t, paths, Pointer("/properties/y/prefixItems/0"))
+	assert.Contains(t, paths, Pointer("/properties/z/not"))
+
+	stop := errors.New("stop")
+	err := Walk(s, func(path Pointer, _ *Schema) error {
+		if path == "/allOf/0" {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+
+	var first []Pointer
+	for path := range Schemas(s) {
+		if len(first) == 2 {
+			break
+		}
+		first = append(first, path)
+	}
+	assert.Equal(t, []Pointer{"", "/$defs/a"}, first)
+}
+
+func TestWalkNil(t *testing.T) {
+	called := false
+	require.NoError(t, Walk(nil, func(Pointer, *Schema) error {
+		called = true
+		return nil
+	}))
+	assert.False(t, called)
+
+	out, err := Rewrite(nil, func(Pointer, *Schema) (*Schema, error) {
+		called = true
+		return &Schema{}, nil
+	})
+	require.NoError(t, err)
+	assert.Nil(t, out)
+	assert.False(t, called)
+}
+
+func TestRewrite(t *testing.T) {
+	s := mustUnmarshalSchema(t, walkSchema)
+
+	out, err := Rewrite(s, func(path Pointer, sub *Schema) (*Schema, error) {
+		switch {
+		case path == "":
+			return &Schema{AllOf: []*Schema{sub}}, nil
+		case path == "/allOf/0/$defs/a":
+			return sub, SkipSubtree
+		case sub.Ref != "":
+			t.Errorf("subtree of %s was not skipped", path)
+		case path == "/allOf/0/properties/z/not":
+			return nil, nil
+		case sub.Type == "null":
+			return &Schema{Type: "string"}, nil
+		case sub.Type == "string":
+			return &Schema{Type: "string", MinLength: new(uint64)}, nil
+		}
+		return sub, nil
+	})
+	require.NoError(t, err)
+	data, err := out.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"allOf": [{
+		"$defs": {"b": {"type": "string", "minLength": 0}, "a": {"items": {"$ref": "#/$defs/b"}}},
+		"allOf": [{"minProperties": 1}],
+		"properties": {
+			"z": {"contentSchema": {"type": "integer"}},
+			"y": {"dependentSchemas": {"k": false}, "prefixItems": [true, {"type": "string"}]}
+		},
+		"additionalProperties": false
+	}]}`, string(data))
+
+	_, err = Rewrite(s, func(path Pointer, sub *Schema) (*Schema, error) {
+		if path == "/allOf/0" {
+			return nil, nil
+		}
+		return sub, nil
+	})
+	assert.Error(t, err)
+}